@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dtnitsch/manifestor/internal/output"
+	"github.com/dtnitsch/manifestor/pkg/manifestor"
+	"github.com/urfave/cli/v2"
+)
+
+func diffCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "diff",
+		Usage:     "Show structured changes between two manifests",
+		ArgsUsage: "OLD NEW",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   "Output format: text, json or yaml",
+				Value:   "text",
+			},
+			&cli.BoolFlag{
+				Name:  "exit-code",
+				Usage: "Exit non-zero when the manifests differ",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 2 {
+				return fmt.Errorf("diff: expected OLD and NEW manifest paths")
+			}
+
+			old, err := manifestor.Load(c.Args().Get(0))
+			if err != nil {
+				return err
+			}
+			cur, err := manifestor.Load(c.Args().Get(1))
+			if err != nil {
+				return err
+			}
+
+			cs := manifestor.Diff(old, cur)
+			if err := output.WriteChangeSet(os.Stdout, c.String("format"), cs); err != nil {
+				return err
+			}
+
+			if c.Bool("exit-code") && !cs.Empty() {
+				return cli.Exit("", 1)
+			}
+			return nil
+		},
+	}
+}
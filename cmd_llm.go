@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dtnitsch/manifestor/internal/input"
+	"github.com/dtnitsch/manifestor/pkg/manifestor"
+	"github.com/urfave/cli/v2"
+)
+
+func llmCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "llm",
+		Usage:     "Generate manifestor.llm.yaml, the entry point for agents reading a manifest",
+		ArgsUsage: "MANIFEST",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Protocol file path",
+				Value:   "manifestor.llm.yaml",
+			},
+			&cli.StringFlag{
+				Name:  "split-dir",
+				Usage: "Directory holding parts written by `manifestor split`, to link from folders",
+			},
+			&cli.IntFlag{
+				Name:  "depth",
+				Usage: "Summarize folders up to this many levels below the root",
+				Value: 2,
+			},
+			&cli.Float64Flag{
+				Name:  "chars-per-token",
+				Usage: "Token estimate: characters of output per token",
+				Value: 3.5,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return fmt.Errorf("llm: expected exactly one manifest path")
+			}
+			path := c.Args().First()
+			if cpt := c.Float64("chars-per-token"); cpt <= 0 {
+				return fmt.Errorf("llm: --chars-per-token must be positive, got %g", cpt)
+			}
+
+			m, err := manifestor.Load(path)
+			if err != nil {
+				return err
+			}
+
+			format := input.FormatFromPath(path)
+			if format == "" {
+				format = "yaml"
+			}
+			if format == "ndjson" {
+				return fmt.Errorf("llm: the protocol file is not available for ndjson manifests")
+			}
+			spec := outputSpec{format: format}
+
+			var splits map[string]string
+			if dir := c.String("split-dir"); dir != "" {
+				splits = findSplitFiles(dir, path, m)
+			}
+			return writeProtocol(c.String("output"), m, spec, protocolConfig{
+				manifestFile:  path,
+				splitFiles:    splits,
+				depth:         c.Int("depth"),
+				charsPerToken: c.Float64("chars-per-token"),
+			})
+		},
+	}
+}
+
+type protocolConfig struct {
+	manifestFile  string
+	splitFiles    map[string]string
+	depth         int
+	charsPerToken float64
+}
+
+// writeProtocol writes the protocol file for m, estimating token costs on
+// m as spec encodes it.
+func writeProtocol(path string, m *manifestor.Manifest, spec outputSpec, pc protocolConfig) error {
+	p, err := manifestor.GenerateProtocol(m, manifestor.ProtocolOptions{
+		File:         filepath.Base(path),
+		ManifestFile: pc.manifestFile,
+		SplitFiles:   pc.splitFiles,
+		Depth:        pc.depth,
+		Estimator:    manifestor.CharsPerToken(pc.charsPerToken),
+		Encode: func(m *manifestor.Manifest) ([]byte, error) {
+			var b bytes.Buffer
+			err := spec.encode(&b, m)
+			return b.Bytes(), err
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("llm protocol: %w", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create protocol file: %w", err)
+	}
+	defer f.Close()
+
+	return manifestor.EncodeProtocol(f, p)
+}
+
+// findSplitFiles maps the directories of m to the split parts of manifest
+// that exist in dir, in any format.
+func findSplitFiles(dir, manifest string, m *manifestor.Manifest) map[string]string {
+	found := make(map[string]string)
+	for _, n := range m.Nodes {
+		if !n.IsDir || n.Path == "." {
+			continue
+		}
+		for _, format := range []string{"yaml", "json", "ndjson"} {
+			file := splitFile(dir, manifest, n.Path, format)
+			if _, err := os.Stat(file); err == nil {
+				found[n.Path] = file
+				break
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dtnitsch/manifestor/internal/input"
+	"github.com/dtnitsch/manifestor/pkg/manifestor"
+	"github.com/urfave/cli/v2"
+)
+
+func mergeCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "merge",
+		Usage:     "Combine sibling manifests into one, rebuilding parent rollups",
+		ArgsUsage: "MANIFEST MANIFEST...",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "output",
+				Aliases:  []string{"o"},
+				Usage:    "Merged manifest file",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   "Output format: json, yaml or ndjson (default: from the output file extension)",
+			},
+			&cli.StringFlag{
+				Name:  "layout",
+				Usage: "Document layout: flat, tree or compact",
+				Value: "flat",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() < 2 {
+				return fmt.Errorf("merge: expected at least two manifest paths")
+			}
+
+			ms := make([]*manifestor.Manifest, 0, c.NArg())
+			for _, path := range c.Args().Slice() {
+				m, err := manifestor.Load(path)
+				if err != nil {
+					return err
+				}
+				ms = append(ms, m)
+			}
+
+			merged, err := manifestor.Merge(ms...)
+			if err != nil {
+				return fmt.Errorf("merge: %w", err)
+			}
+
+			out := c.String("output")
+			format := c.String("format")
+			if format == "" {
+				format = input.FormatFromPath(out)
+			}
+			if format == "" {
+				format = "yaml"
+			}
+			return writeManifest(outputSpec{format: format, layout: c.String("layout")}, out, merged)
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+	"github.com/dtnitsch/manifestor/internal/schema"
+	"github.com/urfave/cli/v2"
+)
+
+func schemaCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "schema",
+		Usage: "Print the JSON Schema for the manifest format",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "version",
+				Usage: "Manifest version to describe",
+				Value: manifest.CurrentVersion,
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Write the schema to this file instead of stdout",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			s, err := schema.Generate(c.String("version"))
+			if err != nil {
+				return err
+			}
+
+			data, err := schema.Marshal(s)
+			if err != nil {
+				return err
+			}
+
+			if out := c.String("output"); out != "" {
+				if err := os.WriteFile(out, data, 0644); err != nil {
+					return fmt.Errorf("write schema: %w", err)
+				}
+				return nil
+			}
+
+			_, err = os.Stdout.Write(data)
+			return err
+		},
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dtnitsch/manifestor/internal/compress"
+	"github.com/dtnitsch/manifestor/internal/input"
+	"github.com/dtnitsch/manifestor/pkg/manifestor"
+	"github.com/urfave/cli/v2"
+)
+
+func splitCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "split",
+		Usage:     "Split a manifest into one manifest per subtree",
+		ArgsUsage: "MANIFEST",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "by-dir",
+				Usage:    "Split point, as depth=N (directories N levels below the root)",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "out-dir",
+				Aliases: []string{"o"},
+				Usage:   "Directory to write the parts to",
+				Value:   ".",
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   "Output format: json, yaml or ndjson (default: the input's format)",
+			},
+			&cli.StringFlag{
+				Name:  "layout",
+				Usage: "Document layout: flat, tree or compact",
+				Value: "flat",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return fmt.Errorf("split: expected exactly one manifest path")
+			}
+			return runSplit(c.Args().First(), c.String("by-dir"), c.String("out-dir"), c.String("format"), c.String("layout"))
+		},
+	}
+}
+
+func runSplit(path, byDir, outDir, format, layout string) error {
+	depth, err := parseSplitDepth(byDir)
+	if err != nil {
+		return err
+	}
+
+	m, err := manifestor.Load(path)
+	if err != nil {
+		return err
+	}
+
+	parts, err := manifestor.Split(m, depth)
+	if err != nil {
+		return err
+	}
+
+	if format == "" {
+		format = input.FormatFromPath(path)
+	}
+	if format == "" {
+		format = "yaml"
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("create %s: %w", outDir, err)
+	}
+
+	written := make(map[string]string, len(parts))
+
+	for _, p := range parts {
+		file := splitFile(outDir, path, p.Dir, format)
+		if other, ok := written[file]; ok {
+			return fmt.Errorf("split: parts %q and %q would both be written to %s", other, p.Dir, file)
+		}
+		written[file] = p.Dir
+
+		if err := writeManifest(outputSpec{format: format, layout: layout}, file, p.Manifest); err != nil {
+			return err
+		}
+		fmt.Printf("%s\t%d nodes\t%s\n", p.Dir, len(p.Manifest.Nodes), file)
+	}
+	return nil
+}
+
+// splitFile names the part of manifest for dir:
+// services/api -> manifest.services__api.yaml; the top part is "top".
+func splitFile(outDir, manifest, dir, format string) string {
+	name := filepath.Base(compress.TrimExt(manifest))
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	slug := "top"
+	if dir != "." {
+		slug = strings.ReplaceAll(filepath.ToSlash(dir), "/", "__")
+	}
+	return filepath.Join(outDir, base+"."+slug+"."+format)
+}
+
+func parseSplitDepth(s string) (int, error) {
+	key, val, ok := strings.Cut(s, "=")
+	if !ok || key != "depth" {
+		return 0, fmt.Errorf("split: --by-dir must be depth=N, got %q", s)
+	}
+
+	depth, err := strconv.Atoi(val)
+	if err != nil || depth < 1 {
+		return 0, fmt.Errorf("split: invalid depth %q", val)
+	}
+	return depth, nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+
+	"github.com/dtnitsch/manifestor/internal/config"
+	"github.com/dtnitsch/manifestor/internal/output"
+	"github.com/dtnitsch/manifestor/pkg/manifestor"
+	"github.com/urfave/cli/v2"
+)
+
+func validateCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "validate",
+		Usage:     "Validate an existing manifest file against its declared capabilities",
+		ArgsUsage: "MANIFEST",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   "Report format: text, json, sarif or junit",
+				Value:   "text",
+			},
+			&cli.BoolFlag{
+				Name:  "lenient",
+				Usage: "Downgrade error-severity invariants to warnings (overrides config)",
+			},
+			&cli.IntFlag{
+				Name:  "max-violations",
+				Usage: "Stop after this many violations, 0 = unlimited (overrides config)",
+			},
+			&cli.StringFlag{
+				Name:  "baseline",
+				Usage: "Baseline file of known violations to suppress (overrides config)",
+			},
+			&cli.StringFlag{
+				Name:  "write-baseline",
+				Usage: "Record all current violations to this baseline file and exit successfully",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return fmt.Errorf("validate: expected exactly one manifest path")
+			}
+			path := c.Args().First()
+
+			logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
+				Level: slog.LevelInfo,
+			}))
+
+			// The config file is optional here; only its validate section is used.
+			cfg, err := config.Load(logger, c.String("config"))
+			if err != nil {
+				if !errors.Is(err, fs.ErrNotExist) {
+					return fmt.Errorf("failed to load config: %w", err)
+				}
+				cfg = &config.Config{}
+			}
+
+			if c.IsSet("lenient") {
+				cfg.Validate.Lenient = c.Bool("lenient")
+			}
+			if c.IsSet("max-violations") {
+				cfg.Validate.MaxViolations = c.Int("max-violations")
+			}
+			if c.IsSet("baseline") {
+				cfg.Validate.Baseline = c.String("baseline")
+			}
+
+			if out := c.String("write-baseline"); out != "" {
+				return runWriteBaseline(path, out, cfg)
+			}
+			return runValidate(path, c.String("format"), cfg)
+		},
+	}
+}
+
+func runValidate(path, format string, cfg *config.Config) error {
+	m, err := manifestor.Load(path)
+	if err != nil {
+		return err
+	}
+
+	opts, err := validateOptions(cfg)
+	if err != nil {
+		return err
+	}
+
+	report, verr := manifestor.Validate(m, opts...)
+	if report == nil {
+		return verr
+	}
+
+	switch format {
+	case "text":
+		err = output.WriteViolationsText(os.Stdout, path, report)
+	case "json":
+		err = output.WriteViolationsJSON(os.Stdout, path, report)
+	case "sarif":
+		err = output.WriteViolationsSARIF(os.Stdout, path, report)
+	case "junit":
+		err = output.WriteViolationsJUnit(os.Stdout, path, report)
+	default:
+		return fmt.Errorf("unsupported report format: %s (supported: text, json, sarif, junit)", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if verr != nil {
+		return fmt.Errorf("%s: %d validation errors", path, report.Summary.Errors)
+	}
+	return nil
+}
+
+// runWriteBaseline validates without a baseline or limit and records every
+// violation found, so later runs only report new ones.
+func runWriteBaseline(path, out string, cfg *config.Config) error {
+	m, err := manifestor.Load(path)
+	if err != nil {
+		return err
+	}
+
+	cfg.Validate.Baseline = ""
+	cfg.Validate.MaxViolations = 0
+
+	opts, err := validateOptions(cfg)
+	if err != nil {
+		return err
+	}
+
+	report, err := manifestor.Validate(m, opts...)
+	if report == nil {
+		return err
+	}
+
+	b := manifestor.NewBaseline(report.Violations)
+	if err := output.WriteBaseline(out, b); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: wrote %d baseline entries to %s\n", path, len(b.Entries), out)
+	return nil
+}
+
+// validateOptions builds validation options from the validate and policies
+// config sections.
+func validateOptions(cfg *config.Config) ([]manifestor.ValidateOption, error) {
+	overrides, err := manifestor.ParseSeverityOverrides(cfg.Validate.SeverityOverrides)
+	if err != nil {
+		return nil, fmt.Errorf("validate.severity_overrides: %w", err)
+	}
+
+	opts := []manifestor.ValidateOption{
+		manifestor.WithSeverityOverrides(overrides),
+		manifestor.WithMaxViolations(cfg.Validate.MaxViolations),
+		manifestor.WithPolicies(cfg.Policies...),
+	}
+	if cfg.Validate.Lenient {
+		opts = append(opts, manifestor.WithLenient())
+	}
+	if cfg.Validate.Baseline != "" {
+		b, err := manifestor.LoadBaseline(cfg.Validate.Baseline)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, manifestor.WithBaseline(b))
+	}
+	return opts, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dtnitsch/manifestor/pkg/manifestor"
+	"github.com/urfave/cli/v2"
+)
+
+func verifyCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "verify",
+		Usage:     "Compare a manifest against the live filesystem and report drift",
+		ArgsUsage: "[--root DIR] MANIFEST",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "root",
+				Aliases: []string{"r"},
+				Usage:   "Directory to compare against (default: the manifest's root; not allowed for multi-root manifests)",
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   "Report format: text or json",
+				Value:   "text",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() > 1 && strings.HasPrefix(c.Args().Get(1), "-") {
+				return fmt.Errorf("verify: flags must come before the manifest path (verify --root DIR MANIFEST)")
+			}
+			if c.NArg() != 1 {
+				return fmt.Errorf("verify: expected exactly one manifest path")
+			}
+			return runVerify(c.Args().First(), c.String("root"), c.String("format"))
+		},
+	}
+}
+
+func runVerify(path, root, format string) error {
+	m, err := manifestor.Load(path)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(m.Roots) > 0 && root != "":
+		return fmt.Errorf("verify: --root cannot be used with a multi-root manifest")
+	case len(m.Roots) > 0:
+		paths := make([]string, len(m.Roots))
+		for i, r := range m.Roots {
+			paths[i] = r.Path
+		}
+		root = strings.Join(paths, ", ")
+	case root == "":
+		root = m.Root
+	}
+
+	report, err := manifestor.Verify(context.Background(), m, root)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "text":
+		for _, d := range report.Drift {
+			line := fmt.Sprintf("%-13s %s", d.Kind, d.Path)
+			switch {
+			case d.Old != "":
+				line += fmt.Sprintf(" (%s -> %s)", d.Old, d.New)
+			case d.New != "":
+				line += fmt.Sprintf(" (%s)", d.New)
+			}
+			fmt.Println(line)
+		}
+		fmt.Printf("%s: %d nodes checked against %s, %d drifted\n", path, report.Checked, root, len(report.Drift))
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("encode verify report: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported report format: %s (supported: text, json)", format)
+	}
+
+	if report.HasDrift() {
+		return fmt.Errorf("%s: drift detected (%d entries)", path, len(report.Drift))
+	}
+	return nil
+}
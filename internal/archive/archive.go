@@ -0,0 +1,204 @@
+// Package archive exposes the members of zip and tar archives as an fs.FS,
+// so the scanner can walk them like directories.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+// Archive formats recognized by file name.
+const (
+	Zip   = "zip"
+	Tar   = "tar"
+	TarGz = "tar.gz"
+)
+
+// Format returns the archive format implied by name, or "" if name does
+// not look like a supported archive. Jars are zip files.
+func Format(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return TarGz
+	case strings.HasSuffix(lower, ".tar"):
+		return Tar
+	case strings.HasSuffix(lower, ".zip"), strings.HasSuffix(lower, ".jar"):
+		return Zip
+	default:
+		return ""
+	}
+}
+
+// MaxMemberSize bounds the archive members held in memory: nested archives
+// the scanner descends into, and zips read from a file without random
+// access. Larger members fail the scan rather than exhaust memory.
+const MaxMemberSize int64 = 256 << 20
+
+// FS is an opened archive. Zip members are read from the archive file on
+// demand, so the FS must be closed when the caller is done with it.
+type FS struct {
+	fs.FS
+	file io.Closer
+}
+
+// Close releases the archive file, if it is still open.
+func (a *FS) Close() error {
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
+
+// Open reads the archive at name in fsys and returns its members as an
+// fs.FS. Sizes and modification times come from the archive headers.
+//
+// Member contents are not kept in memory unless keep reports true for the
+// member's name; the scanner uses this to retain only nested archives it
+// will descend into. Reading a member that was not kept fails.
+func Open(fsys fs.FS, name string, keep func(member string) bool) (*FS, error) {
+	format := Format(name)
+	if format == "" {
+		return nil, fmt.Errorf("%s: not a supported archive", name)
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ra, size, err := readerAt(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	if format == Zip {
+		zr, err := zip.NewReader(ra, size)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &FS{FS: zr, file: f}, nil
+	}
+
+	// Tar members that are needed later are copied out, so the file can go.
+	defer f.Close()
+
+	r := io.Reader(io.NewSectionReader(ra, 0, size))
+	if format == TarGz {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	mfs, err := readTar(r, keep)
+	if err != nil {
+		return nil, err
+	}
+	return &FS{FS: mfs}, nil
+}
+
+// readerAt returns random access to f, buffering it in memory when the
+// underlying file does not support ReadAt (e.g. a member of another archive).
+func readerAt(f fs.File) (io.ReaderAt, int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if ra, ok := f.(io.ReaderAt); ok {
+		return ra, info.Size(), nil
+	}
+
+	data, err := readMember(f, info.Size())
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+// readMember reads an archive member into memory, refusing members over
+// MaxMemberSize whatever their header claims.
+func readMember(r io.Reader, size int64) ([]byte, error) {
+	if size > MaxMemberSize {
+		return nil, fmt.Errorf("member is %d bytes, over the %d byte limit for archives held in memory", size, MaxMemberSize)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, MaxMemberSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > MaxMemberSize {
+		return nil, fmt.Errorf("member is over the %d byte limit for archives held in memory", MaxMemberSize)
+	}
+	return data, nil
+}
+
+func readTar(r io.Reader, keep func(string) bool) (fs.FS, error) {
+	fsys := newMemFS()
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return fsys, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name, ok := memberName(hdr.Name)
+		if !ok {
+			continue
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			fsys.add(name, &memEntry{mode: fs.ModeDir | 0o755, modTime: hdr.ModTime})
+		case tar.TypeReg:
+			e := &memEntry{size: hdr.Size, mode: fs.FileMode(hdr.Mode).Perm(), modTime: hdr.ModTime}
+			if keep != nil && keep(name) {
+				if e.data, err = readMember(tr, hdr.Size); err != nil {
+					return nil, fmt.Errorf("%s: %w", name, err)
+				}
+			}
+			fsys.add(name, e)
+		case tar.TypeLink:
+			// A hard link is another name for an earlier member; it gets
+			// that member's size and contents.
+			e := &memEntry{mode: fs.FileMode(hdr.Mode).Perm(), modTime: hdr.ModTime}
+			if target, ok := memberName(hdr.Linkname); ok {
+				if t, ok := fsys.entries[target]; ok && t.mode.IsRegular() {
+					e.size, e.mode, e.data = t.size, t.mode, t.data
+				}
+			}
+			fsys.add(name, e)
+		case tar.TypeSymlink:
+			// Recorded like the host scanner records links: not followed.
+			fsys.add(name, &memEntry{mode: fs.ModeSymlink | 0o777, modTime: hdr.ModTime})
+		}
+	}
+}
+
+// memberName cleans a header name into an fs.FS name, rejecting entries
+// that would escape the archive root.
+func memberName(name string) (string, bool) {
+	name = strings.TrimPrefix(name, "./")
+	name = strings.Trim(name, "/")
+	if name == "" || name == "." || !fs.ValidPath(name) {
+		return "", false
+	}
+	return name, true
+}
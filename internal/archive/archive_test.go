@@ -0,0 +1,224 @@
+package archive_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/dtnitsch/manifestor/internal/archive"
+)
+
+var mtime = time.Unix(1700000000, 0)
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, data := range files {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: mtime, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zipBytes(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Modified: mtime, Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestFormat(t *testing.T) {
+	for name, want := range map[string]string{
+		"a.tar.gz":    archive.TarGz,
+		"A.TGZ":       archive.TarGz,
+		"a.tar":       archive.Tar,
+		"lib/x.jar":   archive.Zip,
+		"a.zip":       archive.Zip,
+		"a.gz":        "",
+		"tarball.txt": "",
+	} {
+		if got := archive.Format(name); got != want {
+			t.Errorf("Format(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestOpenTarGz(t *testing.T) {
+	src := fstest.MapFS{
+		"rel.tar.gz": {Data: tarGz(t, map[string]string{
+			"./bin/app":      "binary",
+			"docs/README.md": "readme",
+			"../escape":      "nope",
+			"lib/inner.zip":  string(zipBytes(t, map[string]string{"x.txt": "x"})),
+		})},
+	}
+
+	afs, err := archive.Open(src, "rel.tar.gz", func(name string) bool { return archive.Format(name) != "" })
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	if _, err := fs.ReadFile(afs, "bin/app"); err == nil {
+		t.Errorf("unretained member was readable")
+	}
+
+	info, err := fs.Stat(afs, "bin/app")
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if info.Size() != 6 || !info.ModTime().Equal(mtime) {
+		t.Errorf("bin/app = %d bytes at %v", info.Size(), info.ModTime())
+	}
+
+	if _, err := fs.Stat(afs, "escape"); err == nil {
+		t.Errorf("entry escaping the archive root was kept")
+	}
+
+	// Retained nested archive opens in turn.
+	inner, err := archive.Open(afs, "lib/inner.zip", nil)
+	if err != nil {
+		t.Fatalf("open nested: %v", err)
+	}
+	if data, err := fs.ReadFile(inner, "x.txt"); err != nil || string(data) != "x" {
+		t.Errorf("x.txt = %q, %v", data, err)
+	}
+}
+
+func TestTarFSConformance(t *testing.T) {
+	src := fstest.MapFS{"a.tar": {Data: tarBytes(t)}}
+
+	afs, err := archive.Open(src, "a.tar", func(string) bool { return true })
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := fstest.TestFS(afs, "a/b/c.txt", "a/d.txt", "e.txt"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func tarBytes(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range []string{"a/b/c.txt", "a/", "a/d.txt", "e.txt"} {
+		hdr := &tar.Header{Name: name, Mode: 0o644, ModTime: mtime, Typeflag: tar.TypeReg, Size: 3}
+		if name == "a/" {
+			hdr.Typeflag, hdr.Mode, hdr.Size = tar.TypeDir, 0o755, 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Size > 0 {
+			if _, err := tw.Write([]byte("abc")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestOpenZip(t *testing.T) {
+	src := fstest.MapFS{"app.jar": {Data: zipBytes(t, map[string]string{"META-INF/MANIFEST.MF": "m"})}}
+
+	afs, err := archive.Open(src, "app.jar", nil)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := fstest.TestFS(afs, "META-INF/MANIFEST.MF"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenTarMemberLimit(t *testing.T) {
+	// Only the header is needed: the size is checked before reading.
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	hdr := &tar.Header{Name: "huge.jar", Mode: 0o644, Size: archive.MaxMemberSize + 1, ModTime: mtime, Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(hdr); err != nil {
+		t.Fatal(err)
+	}
+	src := fstest.MapFS{"a.tar": {Data: buf.Bytes()}}
+
+	_, err := archive.Open(src, "a.tar", func(string) bool { return true })
+	if err == nil || !strings.Contains(err.Error(), "byte limit") {
+		t.Fatalf("open = %v, want a size limit error", err)
+	}
+
+	// Members that are not kept are only listed, whatever their size.
+	if _, err := archive.Open(src, "a.tar", nil); err == nil || strings.Contains(err.Error(), "byte limit") {
+		t.Fatalf("open without keep = %v, want only the truncation error", err)
+	}
+}
+
+func TestOpenTarHardLink(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, hdr := range []*tar.Header{
+		{Name: "bin/app", Mode: 0o755, Size: 6, ModTime: mtime, Typeflag: tar.TypeReg},
+		{Name: "bin/app-link", Linkname: "bin/app", ModTime: mtime, Typeflag: tar.TypeLink},
+	} {
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Size > 0 {
+			if _, err := tw.Write([]byte("binary")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	afs, err := archive.Open(fstest.MapFS{"a.tar": {Data: buf.Bytes()}}, "a.tar", func(string) bool { return true })
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	info, err := fs.Stat(afs, "bin/app-link")
+	if err != nil {
+		t.Fatalf("hard link not recorded: %v", err)
+	}
+	if info.Size() != 6 || !info.Mode().IsRegular() {
+		t.Errorf("bin/app-link = %d bytes, mode %v", info.Size(), info.Mode())
+	}
+	if data, err := fs.ReadFile(afs, "bin/app-link"); err != nil || string(data) != "binary" {
+		t.Errorf("bin/app-link = %q, %v", data, err)
+	}
+}
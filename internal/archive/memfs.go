@@ -0,0 +1,154 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+)
+
+// memFS is a read-only in-memory tree built from archive headers.
+type memFS struct {
+	entries map[string]*memEntry
+}
+
+type memEntry struct {
+	name     string
+	size     int64
+	mode     fs.FileMode
+	modTime  time.Time
+	data     []byte
+	children []string
+}
+
+func newMemFS() *memFS {
+	return &memFS{entries: map[string]*memEntry{
+		".": {name: ".", mode: fs.ModeDir | 0o755},
+	}}
+}
+
+// add records an entry, synthesizing any parent directories the archive
+// did not list. A later explicit entry replaces a synthesized one.
+func (m *memFS) add(name string, e *memEntry) {
+	e.name = path.Base(name)
+	if old, ok := m.entries[name]; ok {
+		if old.IsDir() && e.IsDir() {
+			e.children = old.children
+		}
+		m.entries[name] = e
+		return
+	}
+
+	m.entries[name] = e
+
+	parent := path.Dir(name)
+	if _, ok := m.entries[parent]; !ok {
+		m.add(parent, &memEntry{mode: fs.ModeDir | 0o755})
+	}
+	p := m.entries[parent]
+	p.children = append(p.children, name)
+}
+
+func (m *memFS) lookup(op, name string) (*memEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	e, ok := m.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return e, nil
+}
+
+func (m *memFS) Open(name string) (fs.File, error) {
+	e, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return &memFile{fsys: m, path: name, entry: e, r: bytes.NewReader(e.data)}, nil
+}
+
+func (m *memFS) Stat(name string) (fs.FileInfo, error) {
+	return m.lookup("stat", name)
+}
+
+func (m *memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	e, err := m.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+
+	out := make([]fs.DirEntry, 0, len(e.children))
+	for _, c := range e.children {
+		out = append(out, fs.FileInfoToDirEntry(m.entries[c]))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out, nil
+}
+
+// memEntry is its own fs.FileInfo.
+func (e *memEntry) Name() string       { return e.name }
+func (e *memEntry) Size() int64        { return e.size }
+func (e *memEntry) Mode() fs.FileMode  { return e.mode }
+func (e *memEntry) ModTime() time.Time { return e.modTime }
+func (e *memEntry) IsDir() bool        { return e.mode.IsDir() }
+func (e *memEntry) Sys() any           { return nil }
+
+type memFile struct {
+	fsys    *memFS
+	path    string
+	entry   *memEntry
+	r       *bytes.Reader
+	dirRead []fs.DirEntry
+	dirPos  int
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.entry, nil }
+func (f *memFile) Close() error               { return nil }
+
+func (f *memFile) Read(p []byte) (int, error) {
+	if f.entry.IsDir() {
+		return 0, &fs.PathError{Op: "read", Path: f.path, Err: errors.New("is a directory")}
+	}
+	if f.entry.data == nil && f.entry.size > 0 {
+		return 0, &fs.PathError{Op: "read", Path: f.path, Err: errors.New("member contents not retained")}
+	}
+	return f.r.Read(p)
+}
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	if f.entry.data == nil && f.entry.size > 0 {
+		return 0, &fs.PathError{Op: "read", Path: f.path, Err: errors.New("member contents not retained")}
+	}
+	return f.r.ReadAt(p, off)
+}
+
+func (f *memFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if f.dirRead == nil {
+		entries, err := f.fsys.ReadDir(f.path)
+		if err != nil {
+			return nil, err
+		}
+		f.dirRead = entries
+	}
+
+	rest := f.dirRead[f.dirPos:]
+	if n <= 0 {
+		f.dirPos = len(f.dirRead)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	f.dirPos += n
+	return rest[:n], nil
+}
@@ -0,0 +1,247 @@
+// Package budget fits manifests into a token budget by collapsing
+// directories into their rollup summaries.
+package budget
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+// Estimator estimates how many tokens an encoded manifest costs.
+type Estimator interface {
+	Name() string
+	Estimate(doc []byte) int
+}
+
+// CharsPerToken estimates tokens from document length.
+type CharsPerToken float64
+
+// DefaultEstimator matches what we measured for manifests with the Claude
+// tokenizer (about 3.5 characters per token).
+const DefaultEstimator CharsPerToken = 3.5
+
+func (c CharsPerToken) Name() string { return fmt.Sprintf("chars/%g", float64(c)) }
+
+func (c CharsPerToken) Estimate(doc []byte) int {
+	return int(math.Ceil(float64(len(doc)) / float64(c)))
+}
+
+// Options controls Fit.
+type Options struct {
+	// Tokens is the budget.
+	Tokens int
+
+	// Estimator defaults to DefaultEstimator.
+	Estimator Estimator
+
+	// Encode renders a manifest the way it will be written, so the
+	// estimate covers the real output format.
+	Encode func(*manifest.Manifest) ([]byte, error)
+}
+
+// Fit returns a copy of m that fits opts.Tokens. The deepest directories
+// are collapsed first and, among equally deep ones, those with the fewest
+// files and the oldest changes. A collapsed directory keeps its node and
+// rollup; its descendants are dropped and summarized in Budget.Collapsed.
+// Top-level nodes are never collapsed. m is not modified.
+func Fit(m *manifest.Manifest, opts Options) (*manifest.Manifest, error) {
+	if opts.Tokens <= 0 {
+		return nil, fmt.Errorf("token budget must be positive, got %d", opts.Tokens)
+	}
+	if opts.Estimator == nil {
+		opts.Estimator = DefaultEstimator
+	}
+
+	t := newTree(m)
+	_, cost, err := NodeCosts(m, opts.Estimator, opts.Encode)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := make(map[string]bool)
+	collapsed := make(map[string]manifest.CollapsedDir)
+	next := 0
+
+	for {
+		out := t.build(removed, &manifest.BudgetMeta{
+			Tokens:    opts.Tokens,
+			Estimator: opts.Estimator.Name(),
+			Estimated: opts.Tokens, // never shorter than the final value
+			Collapsed: sortedCollapsed(collapsed),
+		})
+
+		data, err := opts.Encode(out)
+		if err != nil {
+			return nil, fmt.Errorf("encode manifest: %w", err)
+		}
+		tokens := opts.Estimator.Estimate(data)
+		if tokens <= opts.Tokens {
+			out.Budget.Estimated = tokens
+			return out, nil
+		}
+
+		// Collapse until the per-node estimate covers the excess, then
+		// measure the real document again.
+		excess, saved := tokens-opts.Tokens, 0
+		for saved < excess && next < len(t.candidates) {
+			dir := t.candidates[next]
+			next++
+			if removed[dir.Path] {
+				continue
+			}
+
+			for _, d := range t.descendants[dir.Path] {
+				if !removed[d.Path] {
+					removed[d.Path] = true
+					saved += cost[d.Path]
+				}
+				delete(collapsed, d.Path)
+			}
+			collapsed[dir.Path] = t.summary(dir.Path)
+		}
+
+		if saved == 0 {
+			return nil, fmt.Errorf("manifest needs about %d tokens with every directory collapsed, over the budget of %d", tokens, opts.Tokens)
+		}
+	}
+}
+
+// NodeCosts estimates the tokens of m's document without nodes (base), and
+// each node's share of it: the estimate with only that node, less base.
+func NodeCosts(m *manifest.Manifest, est Estimator, encode func(*manifest.Manifest) ([]byte, error)) (base int, cost map[string]int, err error) {
+	single := *m
+	single.Nodes = nil
+
+	data, err := encode(&single)
+	if err != nil {
+		return 0, nil, fmt.Errorf("encode manifest: %w", err)
+	}
+	base = est.Estimate(data)
+
+	cost = make(map[string]int, len(m.Nodes))
+	for _, n := range m.Nodes {
+		single.Nodes = []*manifest.Node{n}
+		data, err := encode(&single)
+		if err != nil {
+			return 0, nil, fmt.Errorf("encode manifest: %w", err)
+		}
+		cost[n.Path] = max(est.Estimate(data)-base, 1)
+	}
+	return base, cost, nil
+}
+
+// tree indexes a manifest's directories for collapsing.
+type tree struct {
+	m           *manifest.Manifest
+	descendants map[string][]*manifest.Node
+	candidates  []*manifest.Node
+}
+
+func newTree(m *manifest.Manifest) *tree {
+	t := &tree{m: m, descendants: make(map[string][]*manifest.Node)}
+
+	parentOf := m.ParentFunc()
+	present := make(map[string]bool, len(m.Nodes))
+	for _, n := range m.Nodes {
+		present[n.Path] = true
+	}
+
+	// Every ancestor of a node, up to the top level, gains it as a
+	// descendant.
+	depth := make(map[string]int)
+	for _, n := range m.Nodes {
+		d := 0
+		for p := n.Path; p != "."; {
+			parent := parentOf(p)
+			if !present[parent] || parent == p {
+				break
+			}
+			t.descendants[parent] = append(t.descendants[parent], n)
+			p = parent
+			d++
+		}
+		depth[n.Path] = d
+	}
+
+	for _, n := range m.Nodes {
+		if n.IsDir && depth[n.Path] > 0 && len(t.descendants[n.Path]) > 0 {
+			t.candidates = append(t.candidates, n)
+		}
+	}
+
+	summaries := make(map[string]manifest.CollapsedDir, len(t.candidates))
+	for _, n := range t.candidates {
+		summaries[n.Path] = t.summary(n.Path)
+	}
+	sort.SliceStable(t.candidates, func(i, j int) bool {
+		a, b := t.candidates[i], t.candidates[j]
+		if depth[a.Path] != depth[b.Path] {
+			return depth[a.Path] > depth[b.Path]
+		}
+		sa, sb := summaries[a.Path], summaries[b.Path]
+		if sa.Files != sb.Files {
+			return sa.Files < sb.Files
+		}
+		if sa.LastModified != sb.LastModified {
+			return sa.LastModified < sb.LastModified
+		}
+		return a.Path < b.Path
+	})
+	return t
+}
+
+func (t *tree) summary(dir string) manifest.CollapsedDir {
+	c := manifest.CollapsedDir{Path: dir}
+	for _, d := range t.descendants[dir] {
+		if d.IsDir {
+			c.Dirs++
+		} else {
+			c.Files++
+			c.SizeBytes += d.SizeBytes
+			if ext := filepath.Ext(d.Path); ext != "" {
+				if c.Extensions == nil {
+					c.Extensions = make(map[string]int)
+				}
+				c.Extensions[ext]++
+			}
+		}
+		c.LastModified = max(c.LastModified, d.MtimeUnix)
+	}
+	return c
+}
+
+// build copies the manifest without removed nodes, and without skipped
+// entries inside collapsed directories. Node values are shared.
+func (t *tree) build(removed map[string]bool, meta *manifest.BudgetMeta) *manifest.Manifest {
+	out := *t.m
+	out.Budget = meta
+	out.Nodes = make([]*manifest.Node, 0, len(t.m.Nodes)-len(removed))
+	for _, n := range t.m.Nodes {
+		if !removed[n.Path] {
+			out.Nodes = append(out.Nodes, n)
+		}
+	}
+
+	if len(meta.Collapsed) > 0 {
+		out.Skipped = nil
+		for _, s := range t.m.Skipped {
+			if _, ok := out.CollapsedUnder(s.Path); !ok {
+				out.Skipped = append(out.Skipped, s)
+			}
+		}
+	}
+	return &out
+}
+
+func sortedCollapsed(collapsed map[string]manifest.CollapsedDir) []manifest.CollapsedDir {
+	out := make([]manifest.CollapsedDir, 0, len(collapsed))
+	for _, c := range collapsed {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
@@ -0,0 +1,95 @@
+package budget_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/dtnitsch/manifestor/internal/budget"
+	"github.com/dtnitsch/manifestor/internal/manifest"
+	"github.com/dtnitsch/manifestor/internal/scanner"
+)
+
+func scan(t *testing.T) *manifest.Manifest {
+	t.Helper()
+
+	fsys := fstest.MapFS{
+		"README.md":                    {Data: []byte("readme")},
+		"services/api/main.go":         {Data: []byte("package main")},
+		"services/api/handlers/a.go":   {Data: []byte("package handlers")},
+		"services/api/handlers/b.go":   {Data: []byte("package handlers")},
+		"services/api/handlers/c.go":   {Data: []byte("package handlers")},
+		"services/api/handlers/d.json": {Data: []byte("{}")},
+		"libs/log/log.go":              {Data: []byte("package log")},
+	}
+	m, err := scanner.New(scanner.Options{Root: "repo", FS: fsys}, scanner.FilterSet{}).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	m.Manifest = manifest.DefaultManifestMeta()
+	if err := m.BuildRollups(manifest.RollupOptions{EnableDirCounts: true, EnableSizeBytes: true}); err != nil {
+		t.Fatalf("rollups: %v", err)
+	}
+	return m
+}
+
+func encode(m *manifest.Manifest) ([]byte, error) { return json.Marshal(m) }
+
+func estimate(t *testing.T, m *manifest.Manifest) int {
+	t.Helper()
+	data, err := encode(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return budget.DefaultEstimator.Estimate(data)
+}
+
+func TestFitCollapsesDeepestFirst(t *testing.T) {
+	m := scan(t)
+	full := estimate(t, m)
+	nodes := len(m.Nodes)
+
+	out, err := budget.Fit(m, budget.Options{Tokens: full - 10, Encode: encode})
+	if err != nil {
+		t.Fatalf("fit: %v", err)
+	}
+
+	if got := estimate(t, out); got > full-10 || got != out.Budget.Estimated {
+		t.Errorf("estimated %d tokens (recorded %d), budget %d", got, out.Budget.Estimated, full-10)
+	}
+	if len(m.Nodes) != nodes || m.Budget != nil {
+		t.Error("Fit modified its input")
+	}
+
+	want := manifest.CollapsedDir{
+		Path:       "services/api/handlers",
+		Files:      4,
+		SizeBytes:  3*16 + 2,
+		Extensions: map[string]int{".go": 3, ".json": 1},
+	}
+	if !reflect.DeepEqual(out.Budget.Collapsed, []manifest.CollapsedDir{want}) {
+		t.Fatalf("collapsed %+v, want %+v", out.Budget.Collapsed, want)
+	}
+
+	for _, n := range out.Nodes {
+		if strings.HasPrefix(n.Path, want.Path+"/") {
+			t.Errorf("node %s kept under a collapsed directory", n.Path)
+		}
+	}
+	if _, ok := out.CollapsedUnder("services/api/handlers/a.go"); !ok {
+		t.Error("CollapsedUnder does not cover dropped nodes")
+	}
+	if _, ok := out.CollapsedUnder(want.Path); ok {
+		t.Error("CollapsedUnder covers the collapsed directory itself")
+	}
+}
+
+func TestFitRejectsImpossibleBudget(t *testing.T) {
+	_, err := budget.Fit(scan(t), budget.Options{Tokens: 10, Encode: encode})
+	if err == nil || !strings.Contains(err.Error(), "over the budget of 10") {
+		t.Fatalf("err = %v, want an over-budget error", err)
+	}
+}
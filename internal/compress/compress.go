@@ -0,0 +1,108 @@
+// Package compress wraps manifest files in gzip or zstd. Writers pick the
+// compression by name or file extension; readers detect it from the
+// content, so compressed manifests load like plain ones.
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	None = "none"
+	Gzip = "gzip"
+	Zstd = "zstd"
+)
+
+var extensions = map[string]string{
+	".gz":   Gzip,
+	".zst":  Zstd,
+	".zstd": Zstd,
+}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// FromPath returns the compression implied by the extension of path, or
+// None.
+func FromPath(path string) string {
+	if c, ok := extensions[strings.ToLower(filepath.Ext(path))]; ok {
+		return c
+	}
+	return None
+}
+
+// TrimExt removes a compression extension, so manifest.json.zst becomes
+// manifest.json.
+func TrimExt(path string) string {
+	if FromPath(path) == None {
+		return path
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path))
+}
+
+// Check rejects unknown compression names; "" means "from the extension".
+func Check(name string) error {
+	switch name {
+	case "", None, Gzip, Zstd:
+		return nil
+	default:
+		return fmt.Errorf("unsupported compression: %s (supported: gzip, zstd, none)", name)
+	}
+}
+
+// NewWriter compresses what is written to w. Close flushes the compressed
+// stream but does not close w.
+func NewWriter(w io.Writer, name string) (io.WriteCloser, error) {
+	switch name {
+	case "", None:
+		return nopCloser{w}, nil
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, fmt.Errorf("zstd writer: %w", err)
+		}
+		return zw, nil
+	default:
+		return nil, Check(name)
+	}
+}
+
+// NewReader decompresses r if it starts with a gzip or zstd header and
+// passes it through unchanged otherwise.
+func NewReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("gzip reader: %w", err)
+		}
+		return zr, nil
+	case bytes.HasPrefix(head, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("zstd reader: %w", err)
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return io.NopCloser(br), nil
+	}
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,64 @@
+package compress
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	want := []byte(`{"manifest":{"version":"0.3"},"nodes":[]}` + "\n")
+
+	for _, name := range []string{None, Gzip, Zstd} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write(want); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			magic := map[string][]byte{None: []byte("{"), Gzip: gzipMagic, Zstd: zstdMagic}[name]
+			if !bytes.HasPrefix(buf.Bytes(), magic) {
+				t.Errorf("%s output starts with % x", name, buf.Bytes()[:4])
+			}
+
+			r, err := NewReader(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestFromPath(t *testing.T) {
+	for path, want := range map[string]string{
+		"manifest.json":      None,
+		"manifest.json.gz":   Gzip,
+		"manifest.yaml.zst":  Zstd,
+		"manifest.yaml.ZSTD": Zstd,
+		"-":                  None,
+	} {
+		if got := FromPath(path); got != want {
+			t.Errorf("FromPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+
+	if got := TrimExt("out/manifest.json.zst"); got != "out/manifest.json" {
+		t.Errorf("TrimExt = %q", got)
+	}
+	if err := Check("brotli"); err == nil {
+		t.Error("Check accepted an unknown compression")
+	}
+}
@@ -1,12 +1,14 @@
 package config
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
 
 	yaml "gopkg.in/yaml.v3"
 
 	"github.com/dtnitsch/manifestor/internal/filter"
+	"github.com/dtnitsch/manifestor/internal/policy"
 )
 
 type Config struct {
@@ -19,16 +21,38 @@ type Config struct {
 
 	// Validate
 	Validate ValidateConfig `yaml:"validate"`
+
+	// Repository policies, evaluated during validation
+	Policies []policy.Spec `yaml:"policies"`
 }
 
 type ScannerConfig struct {
 	Root           string `yaml:"root"`
+	// Roots scans several directories into one manifest; Root is ignored
+	// when set
+	Roots          []RootConfig `yaml:"roots"`
 	MaxWorkers     int    `yaml:"max_workers"`
 	FollowSymlinks bool   `yaml:"follow_symlinks"`
 
 	CollectInodes     bool `yaml:"collect_inodes"`
 	CollectTimestamps bool `yaml:"collect_timestamps"`
 	CollectFileCounts bool `yaml:"collect_file_counts"`
+
+	// Treat zip/jar/tar/tar.gz files as directories
+	Archives ArchiveConfig `yaml:"archives"`
+}
+
+type RootConfig struct {
+	// Namespace for this root's nodes (default: last element of Path)
+	Name    string  `yaml:"name"`
+	Path    string  `yaml:"path"`
+	// Added to the top-level filters for this root only
+	Filters Filters `yaml:"filters"`
+}
+
+type ArchiveConfig struct {
+	Enable   bool `yaml:"enable"`
+	MaxDepth int  `yaml:"max_depth"`
 }
 
 type RollupConfig struct {
@@ -43,6 +67,18 @@ type RollupConfig struct {
 
 type ValidateConfig struct {
 	Enable bool `yaml:"enable"`  
+
+	// Stop collecting after this many violations (0 = unlimited)
+	MaxViolations int `yaml:"max_violations"`
+
+	// Lenient downgrades error-severity invariants to warnings
+	Lenient bool `yaml:"lenient"`
+
+	// Invariant name -> error | warning | info | off
+	SeverityOverrides map[string]string `yaml:"severity_overrides"`
+
+	// Baseline file of known violations to suppress
+	Baseline string `yaml:"baseline"`
 }
 
 type Filters struct {
@@ -52,7 +88,50 @@ type Filters struct {
 
 type Output struct {
 	Format string `yaml:"format"` // json (v0.1)
+	File   string `yaml:"file"`   // "-" writes to stdout
+	Layout string `yaml:"layout"` // flat (default), tree or compact
+
+	// gzip, zstd or none; empty picks by the file extension (.gz, .zst)
+	Compress string `yaml:"compress"`
+
+	// Markdown and tree formats
+	Render RenderConfig `yaml:"render"`
+
+	// Collapse subtrees until the output fits this many tokens (0 = off)
+	TokenBudget   int     `yaml:"token_budget"`
+	CharsPerToken float64 `yaml:"chars_per_token"`
+
+	// csv and tsv formats
+	CSV CSVConfig `yaml:"csv"`
+
+	// sqlite format
+	SQLite SQLiteConfig `yaml:"sqlite"`
+
+	// manifestor.llm.yaml, written next to the manifest
+	LLM LLMConfig `yaml:"llm"`
+}
+
+type CSVConfig struct {
+	Columns       []string          `yaml:"columns"`        // node columns, in order
+	RollupColumns []string          `yaml:"rollup_columns"` // rollup columns, in order
+	Headers       map[string]string `yaml:"headers"`        // header row names, by column
+	RollupsFile   string            `yaml:"rollups_file"`   // also write rollups here
+}
+
+type SQLiteConfig struct {
+	History bool `yaml:"history"` // append each scan to the history table
+}
+
+type LLMConfig struct {
+	Enable bool   `yaml:"enable"`
 	File   string `yaml:"file"`
+	Depth  int    `yaml:"depth"`
+}
+
+type RenderConfig struct {
+	MaxDepth           int   `yaml:"max_depth"`
+	CollapseBelowFiles int   `yaml:"collapse_below_files"`
+	CollapseBelowBytes int64 `yaml:"collapse_below_bytes"`
 }
 
 func Load(log *slog.Logger, filename string) (*Config, error) {
@@ -67,6 +146,9 @@ func Load(log *slog.Logger, filename string) (*Config, error) {
 	}
 
 	applyDefaults(&cfg)
+	if cfg.Output.CharsPerToken < 0 {
+		return nil, fmt.Errorf("output.chars_per_token must be positive, got %g", cfg.Output.CharsPerToken)
+	}
 	return &cfg, nil
 }
 
@@ -83,5 +165,17 @@ func applyDefaults(cfg *Config) {
 	if cfg.Output.File == "" {
 		cfg.Output.File = "manifest.json"
 	}
+	if cfg.Output.Layout == "" {
+		cfg.Output.Layout = "flat"
+	}
+	if cfg.Output.CharsPerToken == 0 {
+		cfg.Output.CharsPerToken = 3.5
+	}
+	if cfg.Output.LLM.File == "" {
+		cfg.Output.LLM.File = "manifestor.llm.yaml"
+	}
+	if cfg.Output.LLM.Depth == 0 {
+		cfg.Output.LLM.Depth = 2
+	}
 }
 
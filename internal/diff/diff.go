@@ -0,0 +1,269 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+// ManifestRef identifies one side of a comparison.
+type ManifestRef struct {
+	Root        string    `json:"root" yaml:"root"`
+	GeneratedAt time.Time `json:"generated_at" yaml:"generated_at"`
+}
+
+// NodeRef is the subset of a node worth repeating in a change set.
+type NodeRef struct {
+	Path      string `json:"path" yaml:"path"`
+	IsDir     bool   `json:"is_dir,omitempty" yaml:"is_dir,omitempty"`
+	SizeBytes int64  `json:"size_bytes,omitempty" yaml:"size_bytes,omitempty"`
+}
+
+type FieldChange struct {
+	Field string `json:"field" yaml:"field"`
+	Old   string `json:"old" yaml:"old"`
+	New   string `json:"new" yaml:"new"`
+}
+
+type Modification struct {
+	Path    string        `json:"path" yaml:"path"`
+	Changes []FieldChange `json:"changes" yaml:"changes"`
+}
+
+// Move is a node removed at From and added at To that was recognized as the
+// same entry by inode or content hash.
+type Move struct {
+	From  string `json:"from" yaml:"from"`
+	To    string `json:"to" yaml:"to"`
+	IsDir bool   `json:"is_dir,omitempty" yaml:"is_dir,omitempty"`
+	By    string `json:"by" yaml:"by"` // inode | hash
+}
+
+// RollupDelta is the change in a directory's rollup between the two sides.
+type RollupDelta struct {
+	Path            string `json:"path" yaml:"path"`
+	FilesDelta      int    `json:"files_delta,omitempty" yaml:"files_delta,omitempty"`
+	DirsDelta       int    `json:"dirs_delta,omitempty" yaml:"dirs_delta,omitempty"`
+	SizeOld         int64  `json:"size_old" yaml:"size_old"`
+	SizeNew         int64  `json:"size_new" yaml:"size_new"`
+	SizeDelta       int64  `json:"size_delta,omitempty" yaml:"size_delta,omitempty"`
+	LastModifiedOld int64  `json:"last_modified_old,omitempty" yaml:"last_modified_old,omitempty"`
+	LastModifiedNew int64  `json:"last_modified_new,omitempty" yaml:"last_modified_new,omitempty"`
+}
+
+type Summary struct {
+	Added     int   `json:"added" yaml:"added"`
+	Removed   int   `json:"removed" yaml:"removed"`
+	Modified  int   `json:"modified" yaml:"modified"`
+	Moved     int   `json:"moved" yaml:"moved"`
+	SizeDelta int64 `json:"size_delta" yaml:"size_delta"`
+}
+
+// ChangeSet is the structured difference between two manifests.
+type ChangeSet struct {
+	Old      ManifestRef    `json:"old" yaml:"old"`
+	New      ManifestRef    `json:"new" yaml:"new"`
+	Summary  Summary        `json:"summary" yaml:"summary"`
+	Added    []NodeRef      `json:"added,omitempty" yaml:"added,omitempty"`
+	Removed  []NodeRef      `json:"removed,omitempty" yaml:"removed,omitempty"`
+	Modified []Modification `json:"modified,omitempty" yaml:"modified,omitempty"`
+	Moved    []Move         `json:"moved,omitempty" yaml:"moved,omitempty"`
+	Rollups  []RollupDelta  `json:"rollups,omitempty" yaml:"rollups,omitempty"`
+}
+
+func (cs *ChangeSet) Empty() bool {
+	return len(cs.Added) == 0 && len(cs.Removed) == 0 &&
+		len(cs.Modified) == 0 && len(cs.Moved) == 0
+}
+
+// Compare computes the change set from before to after. Nodes are matched by
+// path; unmatched removed/added pairs sharing an inode (and type) or a
+// content hash are reported as moves instead.
+func Compare(before, after *manifest.Manifest) *ChangeSet {
+	cs := &ChangeSet{
+		Old: ManifestRef{Root: before.Root, GeneratedAt: before.Generated},
+		New: ManifestRef{Root: after.Root, GeneratedAt: after.Generated},
+	}
+
+	beforeByPath := index(before)
+	afterByPath := index(after)
+
+	var removed, added []*manifest.Node
+	for _, o := range before.Nodes {
+		n, ok := afterByPath[o.Path]
+		if !ok {
+			removed = append(removed, o)
+			continue
+		}
+		if changes := compareNode(o, n); len(changes) > 0 {
+			cs.Modified = append(cs.Modified, Modification{Path: o.Path, Changes: changes})
+		}
+		if d, ok := rollupDelta(o, n); ok {
+			cs.Rollups = append(cs.Rollups, d)
+		}
+	}
+	for _, n := range after.Nodes {
+		if _, ok := beforeByPath[n.Path]; !ok {
+			added = append(added, n)
+		}
+	}
+
+	removed, added = cs.detectMoves(removed, added)
+
+	for _, n := range removed {
+		cs.Removed = append(cs.Removed, ref(n))
+	}
+	for _, n := range added {
+		cs.Added = append(cs.Added, ref(n))
+	}
+
+	sort.Slice(cs.Added, func(i, j int) bool { return cs.Added[i].Path < cs.Added[j].Path })
+	sort.Slice(cs.Removed, func(i, j int) bool { return cs.Removed[i].Path < cs.Removed[j].Path })
+	sort.Slice(cs.Modified, func(i, j int) bool { return cs.Modified[i].Path < cs.Modified[j].Path })
+	sort.Slice(cs.Moved, func(i, j int) bool { return cs.Moved[i].To < cs.Moved[j].To })
+	sort.Slice(cs.Rollups, func(i, j int) bool { return cs.Rollups[i].Path < cs.Rollups[j].Path })
+
+	cs.Summary = Summary{
+		Added:     len(cs.Added),
+		Removed:   len(cs.Removed),
+		Modified:  len(cs.Modified),
+		Moved:     len(cs.Moved),
+		SizeDelta: totalFileSize(after) - totalFileSize(before),
+	}
+
+	return cs
+}
+
+func (cs *ChangeSet) detectMoves(removed, added []*manifest.Node) ([]*manifest.Node, []*manifest.Node) {
+	type inodeKey struct {
+		inode uint64
+		isDir bool
+	}
+
+	byInode := make(map[inodeKey]*manifest.Node)
+	byHash := make(map[string]*manifest.Node)
+	for _, n := range removed {
+		if n.Inode != 0 {
+			byInode[inodeKey{n.Inode, n.IsDir}] = n
+		}
+		if n.Hash != "" && !n.IsDir {
+			byHash[n.Hash] = n
+		}
+	}
+
+	moved := make(map[*manifest.Node]bool)
+	var stillAdded []*manifest.Node
+
+	for _, n := range added {
+		var from *manifest.Node
+		by := ""
+
+		if n.Inode != 0 {
+			if o, ok := byInode[inodeKey{n.Inode, n.IsDir}]; ok && !moved[o] {
+				from, by = o, "inode"
+			}
+		}
+		if from == nil && n.Hash != "" && !n.IsDir {
+			if o, ok := byHash[n.Hash]; ok && !moved[o] {
+				from, by = o, "hash"
+			}
+		}
+
+		if from == nil {
+			stillAdded = append(stillAdded, n)
+			continue
+		}
+
+		moved[from] = true
+		cs.Moved = append(cs.Moved, Move{From: from.Path, To: n.Path, IsDir: n.IsDir, By: by})
+	}
+
+	var stillRemoved []*manifest.Node
+	for _, n := range removed {
+		if !moved[n] {
+			stillRemoved = append(stillRemoved, n)
+		}
+	}
+
+	return stillRemoved, stillAdded
+}
+
+func compareNode(o, n *manifest.Node) []FieldChange {
+	var changes []FieldChange
+
+	add := func(field string, oldV, newV any) {
+		changes = append(changes, FieldChange{Field: field, Old: fmt.Sprint(oldV), New: fmt.Sprint(newV)})
+	}
+
+	if o.IsDir != n.IsDir {
+		add("is_dir", o.IsDir, n.IsDir)
+	}
+	if o.SizeBytes != n.SizeBytes {
+		add("size_bytes", o.SizeBytes, n.SizeBytes)
+	}
+	// Directory mtimes only move when entries are added or removed, which
+	// is already reported per entry. Inodes identify moves, not changes:
+	// editors that save by rename give a file a new one.
+	if !o.IsDir && !n.IsDir && o.MtimeUnix != n.MtimeUnix && o.MtimeUnix != 0 && n.MtimeUnix != 0 {
+		add("mtime_unix", o.MtimeUnix, n.MtimeUnix)
+	}
+	if o.Hash != n.Hash && o.Hash != "" && n.Hash != "" {
+		add("hash", o.Hash, n.Hash)
+	}
+
+	return changes
+}
+
+func rollupDelta(o, n *manifest.Node) (RollupDelta, bool) {
+	if !o.IsDir || !n.IsDir || (o.Rollup == nil && n.Rollup == nil) {
+		return RollupDelta{}, false
+	}
+
+	var or, nr manifest.Rollup
+	if o.Rollup != nil {
+		or = *o.Rollup
+	}
+	if n.Rollup != nil {
+		nr = *n.Rollup
+	}
+
+	d := RollupDelta{
+		Path:       o.Path,
+		FilesDelta: nr.TotalFiles - or.TotalFiles,
+		DirsDelta:  nr.TotalDescendantDirs - or.TotalDescendantDirs,
+		SizeOld:    or.Size.Total,
+		SizeNew:    nr.Size.Total,
+		SizeDelta:  nr.Size.Total - or.Size.Total,
+	}
+	if or.LastModified != nr.LastModified {
+		d.LastModifiedOld = or.LastModified
+		d.LastModifiedNew = nr.LastModified
+	}
+
+	changed := d.FilesDelta != 0 || d.DirsDelta != 0 || d.SizeDelta != 0 || d.LastModifiedOld != d.LastModifiedNew
+	return d, changed
+}
+
+func index(m *manifest.Manifest) map[string]*manifest.Node {
+	idx := make(map[string]*manifest.Node, len(m.Nodes))
+	for _, n := range m.Nodes {
+		idx[n.Path] = n
+	}
+	return idx
+}
+
+func ref(n *manifest.Node) NodeRef {
+	return NodeRef{Path: n.Path, IsDir: n.IsDir, SizeBytes: n.SizeBytes}
+}
+
+func totalFileSize(m *manifest.Manifest) int64 {
+	var total int64
+	for _, n := range m.Nodes {
+		if !n.IsDir {
+			total += n.SizeBytes
+		}
+	}
+	return total
+}
@@ -0,0 +1,79 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+func TestCompare(t *testing.T) {
+	old := &manifest.Manifest{Nodes: []*manifest.Node{
+		{Path: ".", IsDir: true, Rollup: &manifest.Rollup{TotalFiles: 1}},
+		{Path: "a", IsDir: true, Inode: 10},
+		{Path: "a/keep.go", Inode: 11, SizeBytes: 100},
+		{Path: "a/grow.go", Inode: 12, SizeBytes: 100},
+		{Path: "a/old-name.go", Inode: 13, SizeBytes: 50},
+		{Path: "a/copied.bin", Inode: 14, SizeBytes: 7, Hash: "sha256:abc"},
+		{Path: "gone.txt", Inode: 15, SizeBytes: 5},
+	}}
+	old.Nodes[0].Rollup.Size.Total = 5
+
+	cur := &manifest.Manifest{Nodes: []*manifest.Node{
+		{Path: ".", IsDir: true, Rollup: &manifest.Rollup{TotalFiles: 1}},
+		{Path: "a", IsDir: true, Inode: 10},
+		{Path: "a/keep.go", Inode: 11, SizeBytes: 100},
+		{Path: "a/grow.go", Inode: 12, SizeBytes: 300},
+		{Path: "a/new-name.go", Inode: 13, SizeBytes: 50},
+		{Path: "b/copied.bin", Inode: 99, SizeBytes: 7, Hash: "sha256:abc"},
+		{Path: "new.txt", Inode: 16, SizeBytes: 9},
+	}}
+	cur.Nodes[0].Rollup.Size.Total = 9
+
+	cs := Compare(old, cur)
+
+	if len(cs.Added) != 1 || cs.Added[0].Path != "new.txt" {
+		t.Fatalf("unexpected added: %+v", cs.Added)
+	}
+	if len(cs.Removed) != 1 || cs.Removed[0].Path != "gone.txt" {
+		t.Fatalf("unexpected removed: %+v", cs.Removed)
+	}
+	if len(cs.Modified) != 1 || cs.Modified[0].Path != "a/grow.go" || cs.Modified[0].Changes[0].Field != "size_bytes" {
+		t.Fatalf("unexpected modified: %+v", cs.Modified)
+	}
+
+	want := []Move{
+		{From: "a/old-name.go", To: "a/new-name.go", By: "inode"},
+		{From: "a/copied.bin", To: "b/copied.bin", By: "hash"},
+	}
+	if len(cs.Moved) != len(want) {
+		t.Fatalf("unexpected moves: %+v", cs.Moved)
+	}
+	for i, mv := range want {
+		if cs.Moved[i] != mv {
+			t.Fatalf("move %d: expected %+v, got %+v", i, mv, cs.Moved[i])
+		}
+	}
+
+	if len(cs.Rollups) != 1 || cs.Rollups[0].SizeDelta != 4 {
+		t.Fatalf("unexpected rollup deltas: %+v", cs.Rollups)
+	}
+	if cs.Summary.SizeDelta != 204 {
+		t.Fatalf("expected size delta 204, got %d", cs.Summary.SizeDelta)
+	}
+}
+
+func TestCompareIgnoresInodesAndDirectoryMtimes(t *testing.T) {
+	old := &manifest.Manifest{Nodes: []*manifest.Node{
+		{Path: "a", IsDir: true, Inode: 10, MtimeUnix: 100},
+		{Path: "a/f.go", Inode: 11, MtimeUnix: 100, SizeBytes: 5},
+	}}
+	cur := &manifest.Manifest{Nodes: []*manifest.Node{
+		{Path: "a", IsDir: true, Inode: 20, MtimeUnix: 200},
+		{Path: "a/f.go", Inode: 21, MtimeUnix: 100, SizeBytes: 5},
+	}}
+
+	cs := Compare(old, cur)
+	if len(cs.Added)+len(cs.Removed)+len(cs.Moved)+len(cs.Modified) != 0 {
+		t.Fatalf("expected no changes, got %+v", cs)
+	}
+}
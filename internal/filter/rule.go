@@ -8,7 +8,7 @@ const (
 )
 
 type Rule struct {
-	Pattern string   `yaml:"pattern"`
-	Type    RuleType `yaml:"type"`
+	Pattern string   `json:"pattern" yaml:"pattern"`
+	Type    RuleType `json:"type" yaml:"type"`
 }
 
@@ -0,0 +1,30 @@
+package input
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadBaseline reads a violation baseline written by output.WriteBaseline.
+// JSON is used for .json files, YAML otherwise.
+func LoadBaseline(path string) (*manifest.Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read baseline: %w", err)
+	}
+
+	var b manifest.Baseline
+	if FormatFromPath(path) == "json" {
+		err = json.Unmarshal(data, &b)
+	} else {
+		err = yaml.Unmarshal(data, &b)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode baseline %s: %w", path, err)
+	}
+	return &b, nil
+}
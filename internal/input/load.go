@@ -0,0 +1,240 @@
+package input
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dtnitsch/manifestor/internal/compress"
+	"github.com/dtnitsch/manifestor/internal/manifest"
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads a JSON, YAML or NDJSON manifest from disk, decompressing gzip
+// and zstd files. The format is taken from the file extension, falling back
+// to sniffing the content.
+func Load(path string) (*manifest.Manifest, error) {
+	data, err := readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+
+	format := FormatFromPath(path)
+	if format == "" {
+		format = sniffFormat(data)
+	}
+
+	m, err := Decode(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+func readFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := compress.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// header is the part of a document needed to pick a decoding path.
+type header struct {
+	Manifest *struct {
+		Version string              `json:"version" yaml:"version"`
+		Schema  manifest.SchemaMeta `json:"schema" yaml:"schema"`
+	} `json:"manifest" yaml:"manifest"`
+}
+
+// Decode parses manifest bytes in the given format (json, yaml or ndjson). Documents
+// from older supported versions are migrated to manifest.CurrentVersion;
+// newer versions and unknown schemas are rejected. Tree and compact documents
+// are converted to the flat model.
+func Decode(data []byte, format string) (*manifest.Manifest, error) {
+	var c codec
+	switch format {
+	case "json":
+		c = jsonCodec{}
+	case "yaml":
+		c = yamlCodec{}
+	case "ndjson":
+		return decodeNDJSON(data)
+	default:
+		return nil, fmt.Errorf("unsupported manifest format: %s (supported: json, yaml, ndjson)", format)
+	}
+
+	var h header
+	if err := c.unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("decode %s manifest: %w", format, err)
+	}
+
+	version := "0.1" // v0.1 documents had no manifest metadata block
+	if h.Manifest != nil && h.Manifest.Version != "" {
+		version = h.Manifest.Version
+	}
+	if !supportedVersion(version) {
+		return nil, fmt.Errorf("unsupported manifest version %s (this build reads up to %s)", version, manifest.CurrentVersion)
+	}
+	if h.Manifest != nil {
+		if err := checkSchema(h.Manifest.Schema); err != nil {
+			return nil, err
+		}
+	}
+
+	// The tree and compact layouts were introduced with the current version.
+	if layout := h.layout(); layout != "" {
+		if version != manifest.CurrentVersion {
+			return nil, fmt.Errorf("layout %s requires manifest version %s, got %s", layout, manifest.CurrentVersion, version)
+		}
+		return decodeLayout(c, data, format, layout)
+	}
+
+	if version != manifest.CurrentVersion {
+		migrated, err := migrateBytes(c, data, version)
+		if err != nil {
+			return nil, err
+		}
+		data = migrated
+	}
+
+	var m manifest.Manifest
+	if err := c.unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("decode %s manifest: %w", format, err)
+	}
+	return &m, nil
+}
+
+func (h header) layout() string {
+	if h.Manifest == nil {
+		return ""
+	}
+	return h.Manifest.Schema.Layout
+}
+
+// decodeLayout decodes a tree or compact document and converts it to the
+// flat model.
+func decodeLayout(c codec, data []byte, format, layout string) (*manifest.Manifest, error) {
+	switch layout {
+	case manifest.TreeLayout:
+		var t manifest.TreeManifest
+		if err := c.unmarshal(data, &t); err != nil {
+			return nil, fmt.Errorf("decode %s manifest: %w", format, err)
+		}
+		return t.Flatten(), nil
+	case manifest.CompactLayout:
+		var cm manifest.CompactManifest
+		if err := c.unmarshal(data, &cm); err != nil {
+			return nil, fmt.Errorf("decode %s manifest: %w", format, err)
+		}
+		m, err := cm.Expand()
+		if err != nil {
+			return nil, fmt.Errorf("expand compact manifest: %w", err)
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("unsupported layout %q", layout)
+	}
+}
+
+func migrateBytes(c codec, data []byte, version string) ([]byte, error) {
+	var doc document
+	if err := c.unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("decode v%s manifest: %w", version, err)
+	}
+
+	if _, err := migrate(doc, version); err != nil {
+		return nil, err
+	}
+
+	out, err := c.marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("re-encode migrated manifest: %w", err)
+	}
+	return out, nil
+}
+
+func supportedVersion(v string) bool {
+	if v == manifest.CurrentVersion {
+		return true
+	}
+	for _, mg := range migrations {
+		if mg.from == v {
+			return true
+		}
+	}
+	return false
+}
+
+func checkSchema(s manifest.SchemaMeta) error {
+	if s.Node != "" && !supportedSchemas["node"][s.Node] {
+		return fmt.Errorf("unsupported node schema %q", s.Node)
+	}
+	if s.Rollup != "" && !supportedSchemas["rollup"][s.Rollup] {
+		return fmt.Errorf("unsupported rollup schema %q", s.Rollup)
+	}
+	if s.Layout != "" && !supportedSchemas["layout"][s.Layout] {
+		return fmt.Errorf("unsupported layout %q", s.Layout)
+	}
+	return nil
+}
+
+type codec interface {
+	unmarshal([]byte, any) error
+	marshal(any) ([]byte, error)
+}
+
+type jsonCodec struct{}
+
+// unmarshal keeps numbers as json.Number so large inodes survive migration.
+func (jsonCodec) unmarshal(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func (jsonCodec) marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+type yamlCodec struct{}
+
+func (yamlCodec) unmarshal(data []byte, v any) error { return yaml.Unmarshal(data, v) }
+func (yamlCodec) marshal(v any) ([]byte, error)      { return yaml.Marshal(v) }
+
+// FormatFromPath maps a file extension to a manifest format, or "" if
+// unknown. A compression extension is skipped: manifest.json.zst is json.
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(compress.TrimExt(path))) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".ndjson", ".jsonl":
+		return "ndjson"
+	default:
+		return ""
+	}
+}
+
+func sniffFormat(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		first, _, _ := bytes.Cut(trimmed, []byte("\n"))
+		if bytes.Contains(first, []byte(`"type":"header"`)) {
+			return "ndjson"
+		}
+		return "json"
+	}
+	return "yaml"
+}
@@ -0,0 +1,157 @@
+package input
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dtnitsch/manifestor/internal/compress"
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+const v01JSON = `{
+  "root": ".",
+  "generated_at": "2025-12-30T10:00:00Z",
+  "nodes": [
+    {"path": ".", "is_dir": true, "inode": 9007199254740993, "mtime": 1767000000, "folder_count": 1, "ctime": 1},
+    {"path": "a.go", "size_bytes": 12, "mtime": 1767000001}
+  ]
+}`
+
+const v02YAML = `manifest:
+  version: "0.2"
+  schema:
+    node: node.v1
+    rollup: rollup.v1
+  capabilities:
+    rollup:
+      size_stats: true
+root: .
+generated_at: 2026-01-02T10:00:00Z
+nodes:
+  - path: .
+    is_dir: true
+    rollup:
+      total_files: 1
+      total_descendant_dirs: 0
+      size: {total: 12, min: 12, max: 12, mean: 12, median: 12}
+      last_modified: 0
+  - path: a.go
+    size_bytes: 12
+`
+
+func TestDecode_MigratesV01(t *testing.T) {
+	m, err := Decode([]byte(v01JSON), "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Manifest.Version != manifest.CurrentVersion || m.Manifest.Schema.Node != manifest.NodeSchema {
+		t.Fatalf("unexpected meta after migration: %+v", m.Manifest)
+	}
+
+	root := m.Nodes[0]
+	if root.MtimeUnix != 1767000000 || root.DirectSubdirCount != 1 || root.Inode != 9007199254740993 {
+		t.Fatalf("v0.1 fields not migrated: %+v", root)
+	}
+	if m.Nodes[1].SizeBytes != 12 || m.Nodes[1].MtimeUnix != 1767000001 {
+		t.Fatalf("unexpected file node: %+v", m.Nodes[1])
+	}
+}
+
+func TestDecode_MigratesV02(t *testing.T) {
+	m, err := Decode([]byte(v02YAML), "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Manifest.Version != manifest.CurrentVersion || !m.Manifest.Capabilities.Rollup.SizeStats {
+		t.Fatalf("unexpected meta after migration: %+v", m.Manifest)
+	}
+	if m.Nodes[0].Rollup == nil || m.Nodes[0].Rollup.Size.Total != 12 {
+		t.Fatalf("rollup lost in migration: %+v", m.Nodes[0])
+	}
+}
+
+func TestDecode_RejectsUnsupported(t *testing.T) {
+	cases := map[string]string{
+		"newer version":  `{"manifest": {"version": "9.0"}, "nodes": []}`,
+		"unknown schema": `{"manifest": {"version": "0.3", "schema": {"node": "node.v7"}}, "nodes": []}`,
+	}
+
+	for name, doc := range cases {
+		if _, err := Decode([]byte(doc), "json"); err == nil || !strings.Contains(err.Error(), "unsupported") {
+			t.Errorf("%s: expected unsupported error, got %v", name, err)
+		}
+	}
+}
+
+const ndjsonStream = `{"type":"header","manifest":{"version":"0.3","schema":{"node":"node.v1","rollup":"rollup.v1"}},"root":".","generated_at":"2026-01-02T10:00:00Z"}
+{"type":"node","path":".","is_dir":true}
+{"type":"node","path":"a.go","size_bytes":12}
+{"type":"skipped","path":".git","is_dir":true,"reason":"blocked by filter"}
+{"type":"trailer","nodes":2,"skipped":1,"rollups":[{"path":".","rollup":{"total_files":1,"total_descendant_dirs":0,"size":{"total":12},"last_modified":0}}]}
+`
+
+func TestDecode_NDJSON(t *testing.T) {
+	if got := sniffFormat([]byte(ndjsonStream)); got != "ndjson" {
+		t.Fatalf("sniffed %q", got)
+	}
+
+	m, err := Decode([]byte(ndjsonStream), "ndjson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Nodes) != 2 || len(m.Skipped) != 1 {
+		t.Fatalf("got %d nodes, %d skipped", len(m.Nodes), len(m.Skipped))
+	}
+	if r := m.Nodes[0].Rollup; r == nil || r.Size.Total != 12 {
+		t.Fatalf("trailer rollup not attached: %+v", m.Nodes[0])
+	}
+
+	truncated := ndjsonStream[:strings.Index(ndjsonStream, `{"type":"skipped"`)]
+	if _, err := Decode([]byte(truncated), "ndjson"); err == nil || !strings.Contains(err.Error(), "missing trailer") {
+		t.Errorf("expected missing trailer error, got %v", err)
+	}
+}
+
+func TestLoad_Compressed(t *testing.T) {
+	dir := t.TempDir()
+
+	// The compression is detected from the content, whatever the extension.
+	for name, compression := range map[string]string{
+		"manifest.yaml.zst": compress.Zstd,
+		"manifest.yaml.gz":  compress.Gzip,
+		"manifest.yaml":     compress.Gzip,
+	} {
+		path := filepath.Join(dir, name)
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := compress.NewWriter(f, compression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(v02YAML)); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+
+		m, err := Load(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(m.Nodes) != 2 || m.Nodes[1].Path != "a.go" {
+			t.Errorf("%s: unexpected nodes %+v", name, m.Nodes)
+		}
+	}
+
+	if got := FormatFromPath("manifest.json.zst"); got != "json" {
+		t.Errorf("FormatFromPath(manifest.json.zst) = %q, want json", got)
+	}
+}
@@ -0,0 +1,114 @@
+package input
+
+import (
+	"fmt"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+// document is a manifest decoded generically, before it is bound to
+// manifest.Manifest. Migrations rewrite it in place.
+type document = map[string]any
+
+// migration upgrades a document from one version to the next.
+type migration struct {
+	from, to string
+	apply    func(document) error
+}
+
+// migrations are applied in order until the document reaches
+// manifest.CurrentVersion.
+var migrations = []migration{
+	{from: "0.1", to: "0.2", apply: migrateV01ToV02},
+	{from: "0.2", to: "0.3", apply: migrateV02ToV03},
+}
+
+// supportedSchemas lists the node, rollup and layout schemas this build can
+// read.
+var supportedSchemas = map[string]map[string]bool{
+	"node":   {manifest.NodeSchema: true},
+	"rollup": {manifest.RollupSchema: true},
+	"layout": {manifest.TreeLayout: true, manifest.CompactLayout: true},
+}
+
+// migrate walks doc forward from version to manifest.CurrentVersion and
+// returns the versions it passed through.
+func migrate(doc document, version string) ([]string, error) {
+	var applied []string
+
+	for _, mg := range migrations {
+		if version != mg.from {
+			continue
+		}
+		if err := mg.apply(doc); err != nil {
+			return applied, fmt.Errorf("migrate %s -> %s: %w", mg.from, mg.to, err)
+		}
+		setVersion(doc, mg.to)
+		applied = append(applied, mg.from+"->"+mg.to)
+		version = mg.to
+	}
+
+	if version != manifest.CurrentVersion {
+		return applied, fmt.Errorf("no migration path from version %s to %s", version, manifest.CurrentVersion)
+	}
+	return applied, nil
+}
+
+// migrateV01ToV02 adds the manifest metadata block introduced in v0.2 and
+// renames v0.1 node fields to their current names. v0.1 had no rollups, so
+// no capabilities are declared.
+func migrateV01ToV02(doc document) error {
+	meta := manifest.DefaultManifestMeta()
+	doc["manifest"] = map[string]any{
+		"version": "0.2",
+		"generator": map[string]any{
+			"name":       meta.Generator.Name,
+			"version":    "unknown",
+			"build_time": "unknown",
+		},
+		"schema": map[string]any{
+			"node":   manifest.NodeSchema,
+			"rollup": manifest.RollupSchema,
+		},
+		"capabilities": map[string]any{},
+	}
+
+	nodes, _ := doc["nodes"].([]any)
+	for _, raw := range nodes {
+		n, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("node is not an object: %T", raw)
+		}
+		renameKey(n, "mtime", "mtime_unix")
+		renameKey(n, "folder_count", "direct_subdir_count")
+		delete(n, "ctime")
+		delete(n, "symlink_count")
+	}
+	return nil
+}
+
+// migrateV02ToV03 is a no-op on the data: v0.3 only changed serialization
+// (omitempty on default values), which decoding already tolerates.
+func migrateV02ToV03(document) error {
+	return nil
+}
+
+func setVersion(doc document, version string) {
+	meta, ok := doc["manifest"].(map[string]any)
+	if !ok {
+		meta = map[string]any{}
+		doc["manifest"] = meta
+	}
+	meta["version"] = version
+}
+
+func renameKey(m map[string]any, from, to string) {
+	v, ok := m[from]
+	if !ok {
+		return
+	}
+	if _, exists := m[to]; !exists {
+		m[to] = v
+	}
+	delete(m, from)
+}
@@ -0,0 +1,124 @@
+package input
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+// decodeNDJSON reassembles a streamed manifest: header, nodes, skipped
+// entries and the trailer's rollups. NDJSON was introduced with the current
+// version, so there is nothing to migrate.
+func decodeNDJSON(data []byte) (*manifest.Manifest, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	var (
+		m       *manifest.Manifest
+		trailer *manifest.StreamTrailer
+	)
+
+	for line := 1; ; line++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("ndjson record %d: %w", line, err)
+		}
+
+		var rec struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			return nil, fmt.Errorf("ndjson record %d: %w", line, err)
+		}
+
+		if m == nil && rec.Type != manifest.RecordHeader {
+			return nil, fmt.Errorf("ndjson record %d: expected header, got %q", line, rec.Type)
+		}
+		if trailer != nil {
+			return nil, fmt.Errorf("ndjson record %d: %q after trailer", line, rec.Type)
+		}
+
+		switch rec.Type {
+		case manifest.RecordHeader:
+			if m != nil {
+				return nil, fmt.Errorf("ndjson record %d: duplicate header", line)
+			}
+			var h manifest.StreamHeader
+			if err := json.Unmarshal(raw, &h); err != nil {
+				return nil, fmt.Errorf("ndjson header: %w", err)
+			}
+			if h.Manifest.Version != manifest.CurrentVersion {
+				return nil, fmt.Errorf("unsupported ndjson manifest version %q (this build reads %s)", h.Manifest.Version, manifest.CurrentVersion)
+			}
+			if err := checkSchema(h.Manifest.Schema); err != nil {
+				return nil, err
+			}
+			if h.Manifest.Schema.Layout != "" {
+				return nil, fmt.Errorf("ndjson manifests are flat, got layout %q", h.Manifest.Schema.Layout)
+			}
+			m = &manifest.Manifest{
+				Manifest:  h.Manifest,
+				Root:      h.Root,
+				Roots:     h.Roots,
+				Generated: h.Generated,
+				Filters:   h.Filters,
+				Archives:  h.Archives,
+			}
+
+		case manifest.RecordNode:
+			n := manifest.StreamNode{Node: &manifest.Node{}}
+			if err := json.Unmarshal(raw, &n); err != nil {
+				return nil, fmt.Errorf("ndjson record %d: %w", line, err)
+			}
+			m.Nodes = append(m.Nodes, n.Node)
+
+		case manifest.RecordSkipped:
+			var s manifest.StreamSkipped
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return nil, fmt.Errorf("ndjson record %d: %w", line, err)
+			}
+			m.Skipped = append(m.Skipped, s.SkippedEntry)
+
+		case manifest.RecordTrailer:
+			trailer = &manifest.StreamTrailer{}
+			if err := json.Unmarshal(raw, trailer); err != nil {
+				return nil, fmt.Errorf("ndjson trailer: %w", err)
+			}
+
+		default:
+			return nil, fmt.Errorf("ndjson record %d: unknown type %q", line, rec.Type)
+		}
+	}
+
+	if m == nil {
+		return nil, fmt.Errorf("ndjson: empty stream")
+	}
+	if trailer == nil {
+		return nil, fmt.Errorf("ndjson: missing trailer (truncated stream?)")
+	}
+	if trailer.Nodes != len(m.Nodes) || trailer.Skipped != len(m.Skipped) {
+		return nil, fmt.Errorf("ndjson: trailer expects %d nodes and %d skipped entries, got %d and %d",
+			trailer.Nodes, trailer.Skipped, len(m.Nodes), len(m.Skipped))
+	}
+
+	byPath := make(map[string]*manifest.Node, len(m.Nodes))
+	for _, n := range m.Nodes {
+		byPath[n.Path] = n
+	}
+	for _, r := range trailer.Rollups {
+		n, ok := byPath[r.Path]
+		if !ok {
+			return nil, fmt.Errorf("ndjson: rollup for unknown node %q", r.Path)
+		}
+		n.Rollup = r.Rollup
+		n.DirectSubdirCount = r.DirectSubdirCount
+	}
+
+	return m, nil
+}
@@ -0,0 +1,155 @@
+package llm
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+// entryPointNames are files an agent should usually read first, by
+// basename. Matching is case-insensitive.
+var entryPointNames = []struct {
+	name   string
+	reason string
+}{
+	{"readme", "project overview"},
+	{"readme.md", "project overview"},
+	{"readme.rst", "project overview"},
+	{"readme.txt", "project overview"},
+	{"contributing.md", "contributor guide"},
+	{"architecture.md", "architecture notes"},
+	{"go.mod", "Go module definition"},
+	{"package.json", "npm package definition"},
+	{"pyproject.toml", "Python project definition"},
+	{"setup.py", "Python project definition"},
+	{"cargo.toml", "Rust crate definition"},
+	{"pom.xml", "Maven project definition"},
+	{"build.gradle", "Gradle build"},
+	{"makefile", "build targets"},
+	{"dockerfile", "container build"},
+	{"docker-compose.yml", "local services"},
+	{"docker-compose.yaml", "local services"},
+	{"main.go", "program entry point"},
+	{"main.py", "program entry point"},
+	{"__main__.py", "program entry point"},
+	{"app.py", "program entry point"},
+	{"index.js", "program entry point"},
+	{"index.ts", "program entry point"},
+	{"main.rs", "program entry point"},
+}
+
+// maxEntryPoints bounds the list so it stays a starting point.
+const maxEntryPoints = 20
+
+// entryPoints lists well-known files up to depth levels below the root,
+// shallowest first.
+func entryPoints(m *manifest.Manifest, depth int) []EntryPoint {
+	reasons := make(map[string]string, len(entryPointNames))
+	for _, e := range entryPointNames {
+		reasons[e.name] = e.reason
+	}
+
+	type candidate struct {
+		EntryPoint
+		depth int
+	}
+	var found []candidate
+	for _, n := range m.Nodes {
+		if n.IsDir {
+			continue
+		}
+		reason, ok := reasons[strings.ToLower(filepath.Base(n.Path))]
+		if !ok {
+			continue
+		}
+		d := strings.Count(n.Path, string(filepath.Separator))
+		if d <= depth {
+			found = append(found, candidate{EntryPoint{n.Path, reason}, d})
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].depth != found[j].depth {
+			return found[i].depth < found[j].depth
+		}
+		return found[i].Path < found[j].Path
+	})
+	if len(found) > maxEntryPoints {
+		found = found[:maxEntryPoints]
+	}
+
+	out := make([]EntryPoint, len(found))
+	for i, c := range found {
+		out[i] = c.EntryPoint
+	}
+	return out
+}
+
+func instructions(opts Options, s TokenSavings) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "You are reading a manifestor repository index.\n\n")
+
+	fmt.Fprintf(&b, "LAZY LOADING PROTOCOL:\n")
+	fmt.Fprintf(&b, "1. folders summarizes each directory's whole subtree; answer structure questions from it\n")
+	fmt.Fprintf(&b, "2. entry_points lists files worth reading first\n")
+	fmt.Fprintf(&b, "3. For one directory, load its split_file when it has one\n")
+	if opts.ManifestFile != "" {
+		fmt.Fprintf(&b, "4. Otherwise, or for cross-directory queries, load %s (filter it with yq, see yq_examples)\n", opts.ManifestFile)
+	}
+
+	fmt.Fprintf(&b, "\nTOKEN COSTS (estimated):\n")
+	fmt.Fprintf(&b, "- This file: ~%d tokens\n", s.StructureOnly)
+	fmt.Fprintf(&b, "- One folder: its tokens field\n")
+	if opts.ManifestFile != "" {
+		fmt.Fprintf(&b, "- Full manifest: ~%d tokens\n", s.Baseline)
+	}
+	fmt.Fprintf(&b, "- Always start here and load selectively\n")
+
+	fmt.Fprintf(&b, "\nINVARIANTS:\n")
+	fmt.Fprintf(&b, "- Paths are relative to root\n")
+	fmt.Fprintf(&b, "- Folder files, subfolders and size_bytes include all subdirectories\n")
+	fmt.Fprintf(&b, "- Folders without split_file are only in the full manifest\n")
+	fmt.Fprintf(&b, "- collapsed folders were summarized to fit a token budget; rescan them for details\n")
+	return b.String()
+}
+
+func yqExamples(opts Options, folders []Folder) []YqExample {
+	examples := []YqExample{
+		{"structure_browsing", fmt.Sprintf("yq '.folders[] | {\"path\": .path, \"files\": .files}' %s", opts.File)},
+		{"find_directory", fmt.Sprintf("yq '.folders[] | select(.path | test(\"auth|login|user\"))' %s", opts.File)},
+	}
+
+	for _, f := range folders {
+		if f.SplitFile != "" {
+			examples = append(examples, YqExample{"load_split", fmt.Sprintf("yq '.nodes[].path' %s", f.SplitFile)})
+			break
+		}
+	}
+
+	// Show the prefix query on a real folder when there is one.
+	dir := "src"
+	for _, f := range folders {
+		if f.Path != "." {
+			dir = filepath.ToSlash(f.Path)
+			break
+		}
+	}
+
+	if opts.ManifestFile != "" {
+		examples = append(examples,
+			YqExample{"files_under", fmt.Sprintf("yq '.nodes[] | select(.path | test(\"^%s/\")) | .path' %s", dir, opts.ManifestFile)},
+		)
+		// Filter on the repository's most common extension, if it has any.
+		if len(folders) > 0 && folders[0].Path == "." && len(folders[0].TopExtensions) > 0 {
+			ext := strings.ReplaceAll(regexp.QuoteMeta(folders[0].TopExtensions[0]), `\`, `\\`)
+			examples = append(examples,
+				YqExample{"filter_extensions", fmt.Sprintf("yq '.nodes[] | select(.path | test(\"%s$\")) | .path' %s", ext, opts.ManifestFile)},
+			)
+		}
+	}
+	return examples
+}
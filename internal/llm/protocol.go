@@ -0,0 +1,337 @@
+// Package llm generates manifestor.llm.yaml, the file an agent reads before
+// anything else: the folder structure with per-directory summaries, where
+// to start reading, what each subtree costs to load, and how to load it.
+package llm
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dtnitsch/manifestor/internal/budget"
+	"github.com/dtnitsch/manifestor/internal/manifest"
+	"gopkg.in/yaml.v3"
+)
+
+// ProtocolVersion is versioned apart from manifestor so agents can rely on
+// the file's shape across releases.
+const ProtocolVersion = "1.0"
+
+// DefaultFile is the conventional name of the protocol file.
+const DefaultFile = "manifestor.llm.yaml"
+
+// Protocol is the manifestor.llm.yaml document.
+type Protocol struct {
+	Protocol     ProtocolMeta `yaml:"protocol"`
+	Root         string       `yaml:"root"`
+	Generated    time.Time    `yaml:"generated_at"`
+	Generator    string       `yaml:"manifestor_version"`
+	TokenSavings TokenSavings `yaml:"token_savings"`
+	EntryPoints  []EntryPoint `yaml:"entry_points,omitempty"`
+	Folders      []Folder     `yaml:"folders"`
+}
+
+type ProtocolMeta struct {
+	Version        string          `yaml:"version"`
+	Instructions   string          `yaml:"instructions"`
+	AvailableFiles []AvailableFile `yaml:"available_files"`
+	YqExamples     []YqExample     `yaml:"yq_examples"`
+}
+
+// AvailableFile is a file the agent may load, with what it costs.
+type AvailableFile struct {
+	Path        string `yaml:"path"`
+	Description string `yaml:"description"`
+	Tokens      int    `yaml:"tokens"`
+	UseWhen     string `yaml:"use_when,omitempty"`
+}
+
+type YqExample struct {
+	Name  string `yaml:"name"`
+	Query string `yaml:"query"`
+}
+
+// TokenSavings compares reading the full manifest with reading this file
+// plus one folder's subtree.
+type TokenSavings struct {
+	Baseline          int     `yaml:"baseline"`
+	StructureOnly     int     `yaml:"structure_only"`
+	TypicalQuery      int     `yaml:"typical_query"`
+	SavingsPercentage float64 `yaml:"savings_percentage"`
+}
+
+// EntryPoint is a file worth reading first.
+type EntryPoint struct {
+	Path   string `yaml:"path"`
+	Reason string `yaml:"reason"`
+}
+
+// Folder summarizes a directory's whole subtree.
+type Folder struct {
+	Path          string   `yaml:"path"`
+	Files         int      `yaml:"files"`
+	Subfolders    int      `yaml:"subfolders"`
+	SizeBytes     int64    `yaml:"size_bytes"`
+	TopExtensions []string `yaml:"top_extensions,omitempty"`
+	LastModified  int64    `yaml:"last_modified,omitempty"`
+	Tokens        int      `yaml:"tokens"`
+	SplitFile     string   `yaml:"split_file,omitempty"`
+	Collapsed     bool     `yaml:"collapsed,omitempty"`
+}
+
+// Options controls Generate.
+type Options struct {
+	// File is the protocol file's own path, as agents should refer to it.
+	// Defaults to DefaultFile.
+	File string
+
+	// ManifestFile is the full manifest the protocol describes.
+	ManifestFile string
+
+	// SplitFiles maps directories to their split manifests, if any.
+	SplitFiles map[string]string
+
+	// Depth limits folders to this many levels below the root (default 2).
+	Depth int
+
+	// Estimator defaults to budget.DefaultEstimator.
+	Estimator budget.Estimator
+
+	// Encode renders a manifest the way ManifestFile is written; subtree
+	// token costs are estimated on its output.
+	Encode func(*manifest.Manifest) ([]byte, error)
+}
+
+// Generate builds the protocol document for m.
+func Generate(m *manifest.Manifest, opts Options) (*Protocol, error) {
+	if opts.File == "" {
+		opts.File = DefaultFile
+	}
+	if opts.Depth <= 0 {
+		opts.Depth = 2
+	}
+	if opts.Estimator == nil {
+		opts.Estimator = budget.DefaultEstimator
+	}
+	if opts.Encode == nil {
+		return nil, fmt.Errorf("llm protocol: no manifest encoder")
+	}
+
+	data, err := opts.Encode(m)
+	if err != nil {
+		return nil, fmt.Errorf("encode manifest: %w", err)
+	}
+	full := opts.Estimator.Estimate(data)
+
+	base, cost, err := budget.NodeCosts(m, opts.Estimator, opts.Encode)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Protocol{
+		Protocol:    ProtocolMeta{Version: ProtocolVersion},
+		Root:        m.Root,
+		Generated:   m.Generated,
+		Generator:   m.Manifest.Generator.Version,
+		EntryPoints: entryPoints(m, opts.Depth),
+		Folders:     folders(m, opts, base, cost),
+	}
+
+	// The file's own cost depends on the numbers in it; two passes settle
+	// all but the last digit or so.
+	for range 2 {
+		self, err := p.tokens(opts.Estimator)
+		if err != nil {
+			return nil, err
+		}
+		p.TokenSavings = savings(full, self, p.Folders)
+		p.Protocol.AvailableFiles = availableFiles(opts, full, self, p.Folders)
+		p.Protocol.Instructions = instructions(opts, p.TokenSavings)
+		p.Protocol.YqExamples = yqExamples(opts, p.Folders)
+	}
+	return p, nil
+}
+
+// Encode writes p as YAML under a short header.
+func Encode(w io.Writer, p *Protocol) error {
+	if _, err := fmt.Fprintf(w, "# %s - read this file first.\n# It indexes the repository and explains how to load more of it.\n\n", DefaultFile); err != nil {
+		return fmt.Errorf("write protocol: %w", err)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(p); err != nil {
+		return fmt.Errorf("encode protocol: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("encode protocol: %w", err)
+	}
+	return nil
+}
+
+func (p *Protocol) tokens(est budget.Estimator) (int, error) {
+	var b strings.Builder
+	if err := Encode(&b, p); err != nil {
+		return 0, err
+	}
+	return est.Estimate([]byte(b.String())), nil
+}
+
+// folders summarizes every directory (and archive) up to opts.Depth levels
+// below its top-level node, in manifest order.
+func folders(m *manifest.Manifest, opts Options, base int, cost map[string]int) []Folder {
+	parentOf := m.ParentFunc()
+	present := make(map[string]bool, len(m.Nodes))
+	for _, n := range m.Nodes {
+		present[n.Path] = true
+	}
+
+	byPath := make(map[string]*Folder)
+	exts := make(map[string]map[string]int)
+	addExt := func(dir, ext string, n int) {
+		if exts[dir] == nil {
+			exts[dir] = make(map[string]int)
+		}
+		exts[dir][ext] += n
+	}
+	var out []*Folder
+
+	for _, n := range m.Nodes {
+		d := 0
+		for p := n.Path; p != "."; d++ {
+			parent := parentOf(p)
+			if !present[parent] || parent == p {
+				break
+			}
+			p = parent
+		}
+
+		if (n.IsDir || n.Archive != "") && d <= opts.Depth {
+			f := &Folder{Path: n.Path, Tokens: base + cost[n.Path], SplitFile: opts.SplitFiles[n.Path]}
+			if c, ok := m.Budget.CollapsedAt(n.Path); ok {
+				f.Files, f.Subfolders, f.SizeBytes, f.LastModified = c.Files, c.Dirs, c.SizeBytes, c.LastModified
+				for ext, count := range c.Extensions {
+					addExt(n.Path, ext, count)
+				}
+				f.Collapsed = true
+			}
+			byPath[n.Path] = f
+			out = append(out, f)
+		}
+	}
+
+	// Every node counts toward each summarized ancestor.
+	for _, n := range m.Nodes {
+		for p := n.Path; p != "."; {
+			parent := parentOf(p)
+			if !present[parent] || parent == p {
+				break
+			}
+			p = parent
+
+			f, ok := byPath[p]
+			if !ok {
+				continue
+			}
+			f.Tokens += cost[n.Path]
+			if f.Collapsed {
+				continue
+			}
+			if n.IsDir {
+				f.Subfolders++
+				// A collapsed subtree still counts, from its summary.
+				if c, ok := m.Budget.CollapsedAt(n.Path); ok {
+					f.Files += c.Files
+					f.Subfolders += c.Dirs
+					f.SizeBytes += c.SizeBytes
+					f.LastModified = max(f.LastModified, c.LastModified)
+					for ext, count := range c.Extensions {
+						addExt(p, ext, count)
+					}
+				}
+				continue
+			}
+			f.Files++
+			f.SizeBytes += n.SizeBytes
+			f.LastModified = max(f.LastModified, n.MtimeUnix)
+			if ext := filepath.Ext(n.Path); ext != "" {
+				addExt(p, ext, 1)
+			}
+		}
+	}
+
+	result := make([]Folder, len(out))
+	for i, f := range out {
+		f.TopExtensions = topExtensions(exts[f.Path], 3)
+		result[i] = *f
+	}
+	return result
+}
+
+func topExtensions(exts map[string]int, n int) []string {
+	names := make([]string, 0, len(exts))
+	for ext := range exts {
+		names = append(names, ext)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if exts[names[i]] != exts[names[j]] {
+			return exts[names[i]] > exts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > n {
+		names = names[:n]
+	}
+	return names
+}
+
+// savings compares the full manifest with this file plus the average
+// top-level folder below the root.
+func savings(full, self int, folders []Folder) TokenSavings {
+	s := TokenSavings{Baseline: full, StructureOnly: self, TypicalQuery: self}
+
+	var sum, count int
+	for _, f := range folders {
+		if f.Path != "." && !strings.ContainsRune(f.Path, filepath.Separator) {
+			sum += f.Tokens
+			count++
+		}
+	}
+	if count > 0 {
+		s.TypicalQuery += sum / count
+	}
+	if full > 0 && s.TypicalQuery < full {
+		s.SavingsPercentage = float64(int(1000*(1-float64(s.TypicalQuery)/float64(full)))) / 10
+	}
+	return s
+}
+
+func availableFiles(opts Options, full, self int, folders []Folder) []AvailableFile {
+	files := []AvailableFile{{
+		Path:        opts.File,
+		Description: "This file - start here",
+		Tokens:      self,
+	}}
+	if opts.ManifestFile != "" {
+		files = append(files, AvailableFile{
+			Path:        opts.ManifestFile,
+			Description: "Full manifest - every file and directory",
+			Tokens:      full,
+			UseWhen:     "Global queries, full scans, cross-directory analysis",
+		})
+	}
+	for _, f := range folders {
+		if f.SplitFile != "" {
+			files = append(files, AvailableFile{
+				Path:        f.SplitFile,
+				Description: "Split manifest of " + f.Path,
+				Tokens:      f.Tokens,
+				UseWhen:     "Focused investigation of " + f.Path,
+			})
+		}
+	}
+	return files
+}
@@ -0,0 +1,95 @@
+package llm_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/dtnitsch/manifestor/internal/llm"
+	"github.com/dtnitsch/manifestor/internal/manifest"
+	"github.com/dtnitsch/manifestor/internal/scanner"
+	"gopkg.in/yaml.v3"
+)
+
+func TestGenerateProtocol(t *testing.T) {
+	fsys := fstest.MapFS{
+		"README.md":                {Data: []byte("readme")},
+		"go.mod":                   {Data: []byte("module x")},
+		"cmd/tool/main.go":         {Data: []byte("package main")},
+		"internal/auth/login.go":   {Data: []byte("package auth")},
+		"internal/auth/token.go":   {Data: []byte("package auth")},
+		"internal/auth/deep/x.sql": {Data: []byte("select 1")},
+	}
+	m, err := scanner.New(scanner.Options{Root: "repo", FS: fsys}, scanner.FilterSet{}).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	m.Manifest = manifest.DefaultManifestMeta()
+
+	p, err := llm.Generate(m, llm.Options{
+		ManifestFile: "manifest.json",
+		SplitFiles:   map[string]string{"internal": "parts/manifest.internal.json"},
+		Encode:       func(m *manifest.Manifest) ([]byte, error) { return json.Marshal(m) },
+	})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	var paths []string
+	for _, f := range p.Folders {
+		paths = append(paths, f.Path)
+	}
+	if want := []string{".", "cmd", "cmd/tool", "internal", "internal/auth"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("folders = %v, want %v (depth 2)", paths, want)
+	}
+
+	internal := p.Folders[3]
+	if internal.Files != 3 || internal.Subfolders != 2 || internal.SizeBytes != 32 ||
+		!reflect.DeepEqual(internal.TopExtensions, []string{".go", ".sql"}) ||
+		internal.SplitFile != "parts/manifest.internal.json" {
+		t.Errorf("internal = %+v", internal)
+	}
+	if root := p.Folders[0]; root.Files != 6 || root.Tokens < internal.Tokens || p.TokenSavings.Baseline < root.Tokens {
+		t.Errorf("root = %+v, want every file and the most tokens short of the full manifest (%d)", root, p.TokenSavings.Baseline)
+	}
+
+	wantEntries := []llm.EntryPoint{
+		{Path: "README.md", Reason: "project overview"},
+		{Path: "go.mod", Reason: "Go module definition"},
+		{Path: "cmd/tool/main.go", Reason: "program entry point"},
+	}
+	if !reflect.DeepEqual(p.EntryPoints, wantEntries) {
+		t.Errorf("entry points = %+v, want %+v", p.EntryPoints, wantEntries)
+	}
+
+	var files []string
+	for _, f := range p.Protocol.AvailableFiles {
+		files = append(files, f.Path)
+	}
+	if want := []string{llm.DefaultFile, "manifest.json", "parts/manifest.internal.json"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("available files = %v, want %v", files, want)
+	}
+
+	queries := make(map[string]string)
+	for _, e := range p.Protocol.YqExamples {
+		queries[e.Name] = e.Query
+	}
+	if want := `yq '.nodes[] | select(.path | test("\\.go$")) | .path' manifest.json`; queries["filter_extensions"] != want {
+		t.Errorf("filter_extensions = %q, want %q", queries["filter_extensions"], want)
+	}
+
+	var buf bytes.Buffer
+	if err := llm.Encode(&buf, p); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	var back llm.Protocol
+	if err := yaml.Unmarshal(buf.Bytes(), &back); err != nil {
+		t.Fatalf("protocol file is not YAML: %v", err)
+	}
+	if back.Protocol.Version != llm.ProtocolVersion || len(back.Folders) != len(p.Folders) {
+		t.Errorf("round trip lost data: %+v", back.Protocol)
+	}
+}
@@ -0,0 +1,36 @@
+package manifest
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// ArchiveSep separates an archive node's path from its members' paths:
+// "dist/app.tar.gz!/bin/app" is bin/app inside dist/app.tar.gz.
+const ArchiveSep = "!"
+
+// ArchiveMeta records that archives were expanded during the scan, so later
+// rescans (verify) produce comparable nodes.
+type ArchiveMeta struct {
+	MaxDepth int `json:"max_depth" yaml:"max_depth"`
+}
+
+// ParentFunc returns a parent lookup for node paths. It behaves like
+// filepath.Dir except that top-level archive members resolve to their
+// archive node rather than to a "name!" directory that does not exist.
+func (m *Manifest) ParentFunc() func(path string) string {
+	archives := make(map[string]bool)
+	for _, n := range m.Nodes {
+		if n.Archive != "" {
+			archives[n.Path] = true
+		}
+	}
+
+	return func(path string) string {
+		dir := filepath.Dir(path)
+		if trimmed, ok := strings.CutSuffix(dir, ArchiveSep); ok && archives[trimmed] {
+			return trimmed
+		}
+		return dir
+	}
+}
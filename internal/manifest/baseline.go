@@ -0,0 +1,98 @@
+package manifest
+
+import "sort"
+
+// BaselineEntry identifies a known violation. Entries are matched on path,
+// capability and invariant only, so a violation stays baselined while its
+// details (sizes, counts) change.
+type BaselineEntry struct {
+	Path       string `json:"path,omitempty" yaml:"path,omitempty"`
+	Capability string `json:"capability" yaml:"capability"`
+	Invariant  string `json:"invariant" yaml:"invariant"`
+}
+
+// Baseline is a recorded set of accepted violations. Violations matching an
+// entry are suppressed from reports; entries no longer violated count as fixed.
+type Baseline struct {
+	Entries []BaselineEntry `json:"entries" yaml:"entries"`
+}
+
+// NewBaseline records every violation as a baseline entry, de-duplicated
+// and sorted for stable diffs.
+func NewBaseline(violations []InvariantViolation) *Baseline {
+	seen := make(map[BaselineEntry]bool, len(violations))
+	b := &Baseline{Entries: make([]BaselineEntry, 0, len(violations))}
+
+	for _, v := range violations {
+		e := baselineKey(v)
+		if seen[e] {
+			continue
+		}
+		seen[e] = true
+		b.Entries = append(b.Entries, e)
+	}
+
+	sort.Slice(b.Entries, func(i, j int) bool {
+		a, c := b.Entries[i], b.Entries[j]
+		if a.Path != c.Path {
+			return a.Path < c.Path
+		}
+		if a.Capability != c.Capability {
+			return a.Capability < c.Capability
+		}
+		return a.Invariant < c.Invariant
+	})
+
+	return b
+}
+
+func baselineKey(v InvariantViolation) BaselineEntry {
+	return BaselineEntry{
+		Path:       v.Path,
+		Capability: v.Capability,
+		Invariant:  v.Invariant,
+	}
+}
+
+// baselineMatcher tracks which baseline entries were seen during a run.
+type baselineMatcher struct {
+	known map[BaselineEntry]bool // entry -> seen
+}
+
+func newBaselineMatcher(b *Baseline) *baselineMatcher {
+	if b == nil {
+		return nil
+	}
+	m := &baselineMatcher{known: make(map[BaselineEntry]bool, len(b.Entries))}
+	for _, e := range b.Entries {
+		m.known[e] = false
+	}
+	return m
+}
+
+// match reports whether v is baselined, marking its entry as seen.
+func (m *baselineMatcher) match(v InvariantViolation) bool {
+	if m == nil {
+		return false
+	}
+	k := baselineKey(v)
+	if _, ok := m.known[k]; !ok {
+		return false
+	}
+	m.known[k] = true
+	return true
+}
+
+// fixed counts baseline entries that were not violated in this run.
+func (m *baselineMatcher) fixed() int {
+	if m == nil {
+		return 0
+	}
+	n := 0
+	for _, seen := range m.known {
+		if !seen {
+			n++
+		}
+	}
+	return n
+}
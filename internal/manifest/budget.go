@@ -0,0 +1,55 @@
+package manifest
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// BudgetMeta records that a manifest was fit into a token budget by
+// collapsing directories: their descendants were dropped and only the
+// directory node, with its rollup, remains.
+type BudgetMeta struct {
+	Tokens    int            `json:"tokens" yaml:"tokens"`
+	Estimator string         `json:"estimator" yaml:"estimator"`
+	Estimated int            `json:"estimated" yaml:"estimated"`
+	Collapsed []CollapsedDir `json:"collapsed,omitempty" yaml:"collapsed,omitempty"`
+}
+
+// CollapsedDir summarizes the subtree dropped below a collapsed directory.
+// Rescan Path to expand it again.
+type CollapsedDir struct {
+	Path         string         `json:"path" yaml:"path"`
+	Files        int            `json:"files" yaml:"files"`
+	Dirs         int            `json:"dirs" yaml:"dirs"`
+	SizeBytes    int64          `json:"size_bytes" yaml:"size_bytes"`
+	Extensions   map[string]int `json:"extensions,omitempty" yaml:"extensions,omitempty"`
+	LastModified int64          `json:"last_modified,omitempty" yaml:"last_modified,omitempty"`
+}
+
+// CollapsedUnder returns the collapsed directory whose dropped subtree
+// contains path, if any. The collapsed directory itself is not under it.
+func (m *Manifest) CollapsedUnder(path string) (CollapsedDir, bool) {
+	if m.Budget == nil {
+		return CollapsedDir{}, false
+	}
+	for _, c := range m.Budget.Collapsed {
+		if strings.HasPrefix(path, c.Path+string(filepath.Separator)) ||
+			strings.HasPrefix(path, c.Path+ArchiveSep+string(filepath.Separator)) {
+			return c, true
+		}
+	}
+	return CollapsedDir{}, false
+}
+
+// CollapsedAt returns the entry for a collapsed directory. b may be nil.
+func (b *BudgetMeta) CollapsedAt(path string) (CollapsedDir, bool) {
+	if b == nil {
+		return CollapsedDir{}, false
+	}
+	for _, c := range b.Collapsed {
+		if c.Path == path {
+			return c, true
+		}
+	}
+	return CollapsedDir{}, false
+}
@@ -44,7 +44,7 @@ func TestCapabilitySizeStats_HappyPath(t *testing.T) {
     }
 	withSizeStats(m.Nodes[0].Rollup, 100, 10, 60, 33, 30)
 
-	violations, err := m.Validate(ValidateOptions{
+	report, err := m.Validate(ValidateOptions{
 		Strict: true,
 	})
 
@@ -52,8 +52,8 @@ func TestCapabilitySizeStats_HappyPath(t *testing.T) {
 		t.Fatalf("unexpected fatal error: %v", err)
 	}
 
-	if len(violations) != 0 {
-		t.Fatalf("expected no violations, got %d: %+v", len(violations), violations)
+	if len(report.Violations) != 0 {
+		t.Fatalf("expected no violations, got %d: %+v", len(report.Violations), report.Violations)
 	}
 }
 
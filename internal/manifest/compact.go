@@ -0,0 +1,303 @@
+package manifest
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CompactLayout is the schema.layout marker of compact documents.
+const CompactLayout = "compact.v1"
+
+// CompactManifest is the token-optimized encoding of a manifest:
+//
+//   - node mtimes and rollup last_modified are deltas from MtimeEpoch
+//   - a directory prefix shared by several nodes is stored once in Prefixes
+//     and referenced by its 1-based index
+//   - zero-valued fields and undeclared capabilities are dropped
+//
+// Expand restores the flat manifest exactly.
+type CompactManifest struct {
+	Manifest   CompactMeta    `json:"manifest" yaml:"manifest"`
+	Root       string         `json:"root" yaml:"root"`
+	Roots      []RootMeta     `json:"roots,omitempty" yaml:"roots,omitempty"`
+	Generated  time.Time      `json:"generated_at" yaml:"generated_at"`
+	Filters    *FilterMeta    `json:"filters,omitempty" yaml:"filters,omitempty"`
+	Archives   *ArchiveMeta   `json:"archives,omitempty" yaml:"archives,omitempty"`
+	Budget     *BudgetMeta    `json:"budget,omitempty" yaml:"budget,omitempty"`
+	MtimeEpoch int64          `json:"mtime_epoch,omitempty" yaml:"mtime_epoch,omitempty"`
+	Prefixes   []string       `json:"prefixes,omitempty" yaml:"prefixes,omitempty"`
+	Nodes      []*CompactNode `json:"nodes" yaml:"nodes"`
+	Skipped    []SkippedEntry `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+}
+
+// CompactMeta is ManifestMeta with capabilities listed by name; only
+// declared capabilities appear.
+type CompactMeta struct {
+	Version      string              `json:"version" yaml:"version"`
+	Generator    GeneratorMeta       `json:"generator" yaml:"generator"`
+	Schema       SchemaMeta          `json:"schema" yaml:"schema"`
+	Capabilities CompactCapabilities `json:"capabilities" yaml:"capabilities"`
+}
+
+type CompactCapabilities struct {
+	Rollup []string `json:"rollup,omitempty" yaml:"rollup,omitempty"`
+}
+
+// CompactNode is a Node whose path is Prefixes[Prefix-1] + Name, or just
+// Name when Prefix is 0. A nil MtimeDelta means the node has no mtime.
+type CompactNode struct {
+	Prefix            int            `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	Name              string         `json:"name" yaml:"name"`
+	IsDir             bool           `json:"is_dir,omitempty" yaml:"is_dir,omitempty"`
+	Inode             uint64         `json:"inode,omitempty" yaml:"inode,omitempty"`
+	MtimeDelta        *int64         `json:"mtime_delta,omitempty" yaml:"mtime_delta,omitempty"`
+	SizeBytes         int64          `json:"size_bytes,omitempty" yaml:"size_bytes,omitempty"`
+	Hash              string         `json:"hash,omitempty" yaml:"hash,omitempty"`
+	Archive           string         `json:"archive,omitempty" yaml:"archive,omitempty"`
+	FileCount         int            `json:"file_count,omitempty" yaml:"file_count,omitempty"`
+	DirectSubdirCount int            `json:"direct_subdir_count,omitempty" yaml:"direct_subdir_count,omitempty"`
+	Rollup            *CompactRollup `json:"rollup,omitempty" yaml:"rollup,omitempty"`
+}
+
+// CompactRollup is a Rollup without zero values. A nil LastModifiedDelta
+// means last_modified was 0.
+type CompactRollup struct {
+	TotalFiles          int            `json:"total_files,omitempty" yaml:"total_files,omitempty"`
+	TotalDescendantDirs int            `json:"total_descendant_dirs,omitempty" yaml:"total_descendant_dirs,omitempty"`
+	Extensions          map[string]int `json:"extensions,omitempty" yaml:"extensions,omitempty"`
+	Size                *CompactSize   `json:"size,omitempty" yaml:"size,omitempty"`
+	LastModifiedDelta   *int64         `json:"last_modified_delta,omitempty" yaml:"last_modified_delta,omitempty"`
+}
+
+// CompactSize has the fields of Rollup.Size, with total also omitted when 0.
+type CompactSize struct {
+	Total       int64        `json:"total,omitempty" yaml:"total,omitempty"`
+	Min         int64        `json:"min,omitempty" yaml:"min,omitempty"`
+	Max         int64        `json:"max,omitempty" yaml:"max,omitempty"`
+	Mean        int64        `json:"mean,omitempty" yaml:"mean,omitempty"`
+	Median      int64        `json:"median,omitempty" yaml:"median,omitempty"`
+	Percentiles *Percentiles `json:"percentiles,omitempty" yaml:"percentiles,omitempty"`
+	Buckets     *SizeBuckets `json:"buckets,omitempty" yaml:"buckets,omitempty"`
+}
+
+// rollupCapabilityNames maps capability names, as written in manifests, to
+// their flags.
+var rollupCapabilityNames = []struct {
+	name string
+	flag func(*RollupCapabilities) *bool
+}{
+	{"size_stats", func(c *RollupCapabilities) *bool { return &c.SizeStats }},
+	{"size_percentiles", func(c *RollupCapabilities) *bool { return &c.SizePercentiles }},
+	{"size_buckets", func(c *RollupCapabilities) *bool { return &c.SizeBuckets }},
+	{"activity_span", func(c *RollupCapabilities) *bool { return &c.ActivitySpan }},
+	{"dir_counts", func(c *RollupCapabilities) *bool { return &c.DirCounts }},
+	{"depth_stats", func(c *RollupCapabilities) *bool { return &c.DepthStats }},
+	{"depth_metrics", func(c *RollupCapabilities) *bool { return &c.DepthMetrics }},
+	{"extension_counts", func(c *RollupCapabilities) *bool { return &c.ExtensionCounts }},
+	{"file_types", func(c *RollupCapabilities) *bool { return &c.FileTypes }},
+}
+
+// RollupCapabilityNames lists every rollup capability name in manifest order.
+func RollupCapabilityNames() []string {
+	names := make([]string, len(rollupCapabilityNames))
+	for i, c := range rollupCapabilityNames {
+		names[i] = c.name
+	}
+	return names
+}
+
+// Compact converts m to the compact encoding.
+func (m *Manifest) Compact() *CompactManifest {
+	c := &CompactManifest{
+		Manifest: CompactMeta{
+			Version:   m.Manifest.Version,
+			Generator: m.Manifest.Generator,
+			Schema:    m.Manifest.Schema,
+		},
+		Root:      m.Root,
+		Roots:     m.Roots,
+		Generated: m.Generated,
+		Filters:   m.Filters,
+		Archives:  m.Archives,
+		Budget:    m.Budget,
+		Nodes:     make([]*CompactNode, 0, len(m.Nodes)),
+		Skipped:   m.Skipped,
+	}
+	c.Manifest.Schema.Layout = CompactLayout
+
+	caps := m.Manifest.Capabilities.Rollup
+	for _, rc := range rollupCapabilityNames {
+		if *rc.flag(&caps) {
+			c.Manifest.Capabilities.Rollup = append(c.Manifest.Capabilities.Rollup, rc.name)
+		}
+	}
+
+	c.MtimeEpoch = mtimeEpoch(m.Nodes)
+	delta := func(t int64) *int64 {
+		if t == 0 {
+			return nil
+		}
+		d := t - c.MtimeEpoch
+		return &d
+	}
+
+	// Only prefixes shared by at least two nodes earn a dictionary entry.
+	uses := make(map[string]int)
+	for _, n := range m.Nodes {
+		if prefix, _ := splitPrefix(n.Path); prefix != "" {
+			uses[prefix]++
+		}
+	}
+	ids := make(map[string]int)
+
+	for _, n := range m.Nodes {
+		cn := &CompactNode{
+			Name:              n.Path,
+			IsDir:             n.IsDir,
+			Inode:             n.Inode,
+			MtimeDelta:        delta(n.MtimeUnix),
+			SizeBytes:         n.SizeBytes,
+			Hash:              n.Hash,
+			Archive:           n.Archive,
+			FileCount:         n.FileCount,
+			DirectSubdirCount: n.DirectSubdirCount,
+		}
+
+		if prefix, name := splitPrefix(n.Path); uses[prefix] > 1 {
+			id, ok := ids[prefix]
+			if !ok {
+				c.Prefixes = append(c.Prefixes, prefix)
+				id = len(c.Prefixes)
+				ids[prefix] = id
+			}
+			cn.Prefix, cn.Name = id, name
+		}
+
+		if r := n.Rollup; r != nil {
+			cr := &CompactRollup{
+				TotalFiles:          r.TotalFiles,
+				TotalDescendantDirs: r.TotalDescendantDirs,
+				Extensions:          r.Extensions,
+				LastModifiedDelta:   delta(r.LastModified),
+			}
+			if size := CompactSize(r.Size); size != (CompactSize{}) {
+				cr.Size = &size
+			}
+			cn.Rollup = cr
+		}
+
+		c.Nodes = append(c.Nodes, cn)
+	}
+	return c
+}
+
+// Expand converts c back to the flat manifest.
+func (c *CompactManifest) Expand() (*Manifest, error) {
+	m := &Manifest{
+		Manifest: ManifestMeta{
+			Version:   c.Manifest.Version,
+			Generator: c.Manifest.Generator,
+			Schema:    c.Manifest.Schema,
+		},
+		Root:      c.Root,
+		Roots:     c.Roots,
+		Generated: c.Generated,
+		Filters:   c.Filters,
+		Archives:  c.Archives,
+		Budget:    c.Budget,
+		Nodes:     make([]*Node, 0, len(c.Nodes)),
+		Skipped:   c.Skipped,
+	}
+	m.Manifest.Schema.Layout = ""
+
+	for _, name := range c.Manifest.Capabilities.Rollup {
+		found := false
+		for _, rc := range rollupCapabilityNames {
+			if rc.name == name {
+				*rc.flag(&m.Manifest.Capabilities.Rollup) = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown rollup capability %q", name)
+		}
+	}
+
+	abs := func(d *int64) int64 {
+		if d == nil {
+			return 0
+		}
+		return c.MtimeEpoch + *d
+	}
+
+	for i, cn := range c.Nodes {
+		path := cn.Name
+		if cn.Prefix != 0 {
+			if cn.Prefix < 0 || cn.Prefix > len(c.Prefixes) {
+				return nil, fmt.Errorf("node %d (%s): prefix %d out of range", i, cn.Name, cn.Prefix)
+			}
+			path = c.Prefixes[cn.Prefix-1] + cn.Name
+		}
+
+		n := &Node{
+			Path:              path,
+			IsDir:             cn.IsDir,
+			Inode:             cn.Inode,
+			MtimeUnix:         abs(cn.MtimeDelta),
+			SizeBytes:         cn.SizeBytes,
+			Hash:              cn.Hash,
+			Archive:           cn.Archive,
+			FileCount:         cn.FileCount,
+			DirectSubdirCount: cn.DirectSubdirCount,
+		}
+
+		if cr := cn.Rollup; cr != nil {
+			r := &Rollup{
+				TotalFiles:          cr.TotalFiles,
+				TotalDescendantDirs: cr.TotalDescendantDirs,
+				Extensions:          cr.Extensions,
+				LastModified:        abs(cr.LastModifiedDelta),
+			}
+			if cr.Size != nil {
+				r.Size.Total = cr.Size.Total
+				r.Size.Min = cr.Size.Min
+				r.Size.Max = cr.Size.Max
+				r.Size.Mean = cr.Size.Mean
+				r.Size.Median = cr.Size.Median
+				r.Size.Percentiles = cr.Size.Percentiles
+				r.Size.Buckets = cr.Size.Buckets
+			}
+			n.Rollup = r
+		}
+
+		m.Nodes = append(m.Nodes, n)
+	}
+	return m, nil
+}
+
+// mtimeEpoch is the oldest timestamp in the manifest, so every delta is
+// non-negative.
+func mtimeEpoch(nodes []*Node) int64 {
+	var epoch int64
+	see := func(t int64) {
+		if t != 0 && (epoch == 0 || t < epoch) {
+			epoch = t
+		}
+	}
+	for _, n := range nodes {
+		see(n.MtimeUnix)
+		if n.Rollup != nil {
+			see(n.Rollup.LastModified)
+		}
+	}
+	return epoch
+}
+
+// splitPrefix splits a path after its last separator; the prefix keeps the
+// separator ("src/util/" + "a.go").
+func splitPrefix(path string) (prefix, name string) {
+	i := strings.LastIndexByte(path, filepath.Separator)
+	return path[:i+1], path[i+1:]
+}
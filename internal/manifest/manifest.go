@@ -1,17 +1,31 @@
 package manifest
 
 import (
+	"strings"
 	"time"
+
+	"github.com/dtnitsch/manifestor/internal/filter"
 )
 
 type Manifest struct {
 	Manifest  ManifestMeta   `json:"manifest" yaml:"manifest"`
     Root      string         `json:"root" yaml:"root"`
+    Roots     []RootMeta     `json:"roots,omitempty" yaml:"roots,omitempty"`
     Generated time.Time      `json:"generated_at" yaml:"generated_at"`
+    Filters   *FilterMeta    `json:"filters,omitempty" yaml:"filters,omitempty"`
+    Archives  *ArchiveMeta   `json:"archives,omitempty" yaml:"archives,omitempty"`
+    Budget    *BudgetMeta    `json:"budget,omitempty" yaml:"budget,omitempty"`
     Nodes     []*Node        `json:"nodes" yaml:"nodes"`
     Skipped   []SkippedEntry `json:"skipped,omitempty" yaml:"skipped,omitempty"`
 }
 
+// FilterMeta records the filter rules a manifest was scanned with, so later
+// comparisons against the filesystem can be scoped the same way.
+type FilterMeta struct {
+	Block []filter.Rule `json:"block,omitempty" yaml:"block,omitempty"`
+	Allow []filter.Rule `json:"allow,omitempty" yaml:"allow,omitempty"`
+}
+
 type SkippedEntry struct {
     Path   string `json:"path" yaml:"path"`
     IsDir  bool   `json:"is_dir,omitempty" yaml:"is_dir,omitempty"`
@@ -36,6 +50,9 @@ type GeneratorMeta struct {
 type SchemaMeta struct {
 	Node   string `json:"node" yaml:"node"`
 	Rollup string `json:"rollup" yaml:"rollup"`
+
+	// Document layout; empty for the flat nodes list, TreeLayout otherwise
+	Layout string `json:"layout,omitempty" yaml:"layout,omitempty"`
 }
 
 
@@ -49,3 +66,33 @@ func (m *Manifest) PrettySkipped() []string {
 	return output
 }
 
+
+// ScanFilters returns the filter rules the manifest was scanned with. Older
+// manifests without recorded filters fall back to the block rules referenced
+// by their skipped entries.
+func (m *Manifest) ScanFilters() FilterMeta {
+	// Multi-root manifests always record filters; their skipped entries
+	// also reference per-root rules.
+	if m.Filters != nil || len(m.Roots) > 0 {
+		if m.Filters == nil {
+			return FilterMeta{}
+		}
+		return *m.Filters
+	}
+
+	var f FilterMeta
+	seen := make(map[string]bool)
+	for _, s := range m.Skipped {
+		if s.Rule == "" || seen[s.Rule] {
+			continue
+		}
+		seen[s.Rule] = true
+
+		typ, pattern, ok := strings.Cut(s.Rule, ":")
+		if !ok {
+			continue
+		}
+		f.Block = append(f.Block, filter.Rule{Type: filter.RuleType(typ), Pattern: pattern})
+	}
+	return f
+}
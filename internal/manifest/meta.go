@@ -2,9 +2,16 @@ package manifest
 
 import "github.com/dtnitsch/manifestor/internal/build"
 
+// Current document format written by this build.
+const (
+    CurrentVersion = "0.3"
+    NodeSchema     = "node.v1"
+    RollupSchema   = "rollup.v1"
+)
+
 func DefaultManifestMeta() ManifestMeta {
     return ManifestMeta{
-        Version: "0.3",
+        Version: CurrentVersion,
         Generator: GeneratorMeta{
             Name:      build.Name,
             Version:   build.Version,
@@ -12,9 +19,8 @@ func DefaultManifestMeta() ManifestMeta {
             Commit:    build.CommitSHA,
         },
         Schema: SchemaMeta{
-            Node:   "node.v1",
-            Rollup: "rollup.v1",
+            Node:   NodeSchema,
+            Rollup: RollupSchema,
         },
     }
 }
-
@@ -9,6 +9,13 @@ type Node struct {
 	MtimeUnix int64  `json:"mtime_unix,omitempty" yaml:"mtime_unix,omitempty"`
 	SizeBytes   int64  `json:"size_bytes,omitempty" yaml:"size_bytes,omitempty"`
 
+	// Optional content hash ("sha256:<hex>"); never computed by the scanner
+	Hash        string `json:"hash,omitempty" yaml:"hash,omitempty"`
+
+	// Archive format ("zip", "tar", "tar.gz") when an archive file was
+	// expanded into a directory node; members follow under Path + "!/"
+	Archive     string `json:"archive,omitempty" yaml:"archive,omitempty"`
+
 	// Immediate directory stats (scanner)
 	FileCount   int `json:"file_count,omitempty" yaml:"file_count,omitempty"`
 	DirectSubdirCount int `json:"direct_subdir_count,omitempty" yaml:"direct_subdir_count,omitempty"`
@@ -79,7 +79,22 @@ type Percentiles struct {
 }
 
 type ValidateOptions struct {
+	// Strict keeps error-severity invariants fatal. When false (lenient mode)
+	// they are downgraded to warnings. Explicit overrides are applied as-is.
 	Strict   bool
+
+	// SeverityOverrides maps an invariant name to the severity it should be
+	// reported with; SeverityOff drops the invariant entirely.
+	SeverityOverrides map[string]Severity
+
+	// Checkers run after the built-in invariants.
+	Checkers []Checker
+
+	// Baseline suppresses known violations; only new ones are reported.
+	Baseline *Baseline
+
+	// MaxViolations caps how many violations are collected (0 = unlimited).
+	MaxViolations int
 }
 
 func (m *Manifest) BuildRollups(opts RollupOptions) error {
@@ -98,9 +113,10 @@ func (m *Manifest) BuildRollups(opts RollupOptions) error {
 
 	// 2. Build parent → children map
 	children := make(map[string][]*Node)
+	parentOf := m.ParentFunc()
 
 	for _, n := range m.Nodes {
-	    parent := filepath.Dir(n.Path)
+	    parent := parentOf(n.Path)
 	    children[parent] = append(children[parent], n)
 	}
 
@@ -133,117 +149,134 @@ func (m *Manifest) BuildRollups(opts RollupOptions) error {
 
 	// 4. Build rollups bottom-up
 	for _, dir := range dirs {
-		r := &Rollup{}
-
-		var (
-			sizeSamples []int64
-			lastMod     int64
-		)
-
-		for _, child := range children[dir.Path] {
-			if child.IsDir {
-			    if opts.EnableDirCounts {
-        			r.TotalDescendantDirs++ // direct child
-	    		    if child.Rollup != nil {
-    	       			r.TotalDescendantDirs += child.Rollup.TotalDescendantDirs
-        			}
-    			}
-			} else {
-				r.TotalFiles++
-
-				if opts.EnableFileTypes {
-					ext := filepath.Ext(child.Path)
-					if ext != "" {
-						if r.Extensions == nil {
-							r.Extensions = make(map[string]int)
-						}
-						r.Extensions[ext]++
-					}
-				}
+		dir.Rollup = computeRollup(children[dir.Path], opts)
+	}
 
-				if opts.EnableSizeBytes && child.SizeBytes > 0 {
-					sizeSamples = append(sizeSamples, child.SizeBytes)
-					r.Size.Total += child.SizeBytes
+	if len(dirs) > 0 {
+		m.Manifest.Capabilities.Rollup = rollupCapabilities(opts)
+	}
+
+	return nil
+}
+
+// computeRollup builds a directory's rollup from its direct children, whose
+// own rollups must already be built.
+func computeRollup(kids []*Node, opts RollupOptions) *Rollup {
+	r := &Rollup{}
+
+	var (
+		sizeSamples []int64
+		lastMod     int64
+	)
+
+	for _, child := range kids {
+		if child.IsDir {
+		    if opts.EnableDirCounts {
+        		r.TotalDescendantDirs++ // direct child
+	    	    if child.Rollup != nil {
+    	       		r.TotalDescendantDirs += child.Rollup.TotalDescendantDirs
+        		}
+    		}
+		} else {
+			r.TotalFiles++
+
+			if opts.EnableFileTypes {
+				ext := filepath.Ext(child.Path)
+				if ext != "" {
+					if r.Extensions == nil {
+						r.Extensions = make(map[string]int)
+					}
+					r.Extensions[ext]++
 				}
 			}
 
-			if child.MtimeUnix > lastMod {
-				lastMod = child.MtimeUnix
+			if opts.EnableSizeBytes && child.SizeBytes > 0 {
+				sizeSamples = append(sizeSamples, child.SizeBytes)
+				r.Size.Total += child.SizeBytes
 			}
 		}
 
-		// 5. Finalize size stats
-		if opts.EnableSizeBytes && len(sizeSamples) > 0 {
-		    sort.Slice(sizeSamples, func(i, j int) bool {
-		        return sizeSamples[i] < sizeSamples[j]
-		    })
-
-		    r.Size.Min = sizeSamples[0]
-		    r.Size.Max = sizeSamples[len(sizeSamples)-1]
-		    r.Size.Mean = r.Size.Total / int64(len(sizeSamples))
-
-		    if opts.EnablePercentiles {
-		        r.Size.Percentiles = computePercentiles(sizeSamples)
-		        r.Size.Median = r.Size.Percentiles.P50
-		    } else {
-		        r.Size.Median = median(sizeSamples)
-		    }
-		}
-		r.LastModified = lastMod
-
-		m.Manifest.Capabilities.Rollup = RollupCapabilities {
-		    SizeStats:    opts.EnableSizeBytes,
-		    SizeBuckets:  false, // future
-		    //SizePercentiles:  opts.EnablePercentiles,
-		    ActivitySpan: false, // future
-		    FileTypes:    opts.EnableFileTypes,
-		    DepthMetrics: opts.EnableDepthStats,
+		if child.MtimeUnix > lastMod {
+			lastMod = child.MtimeUnix
 		}
+	}
 
-		// Attach rollup
-		dir.Rollup = r
+	// 5. Finalize size stats
+	if opts.EnableSizeBytes && len(sizeSamples) > 0 {
+	    sort.Slice(sizeSamples, func(i, j int) bool {
+	        return sizeSamples[i] < sizeSamples[j]
+	    })
+
+	    r.Size.Min = sizeSamples[0]
+	    r.Size.Max = sizeSamples[len(sizeSamples)-1]
+	    r.Size.Mean = r.Size.Total / int64(len(sizeSamples))
+
+	    if opts.EnablePercentiles {
+	        r.Size.Percentiles = computePercentiles(sizeSamples)
+	        r.Size.Median = r.Size.Percentiles.P50
+	    } else {
+	        r.Size.Median = median(sizeSamples)
+	    }
 	}
+	r.LastModified = lastMod
 
-	return nil
+	return r
 }
 
-func validateNode(n *Node, opts ValidateOptions) error {
-	if n.Rollup != nil {
-		if err := validateRollup(n); err != nil {
-			return err
-		}
+// rollupCapabilities is what BuildRollups declares for opts.
+func rollupCapabilities(opts RollupOptions) RollupCapabilities {
+	return RollupCapabilities {
+	    SizeStats:    opts.EnableSizeBytes,
+	    SizeBuckets:  false, // future
+	    //SizePercentiles:  opts.EnablePercentiles,
+	    ActivitySpan: false, // future
+	    FileTypes:    opts.EnableFileTypes,
+	    DepthMetrics: opts.EnableDepthStats,
 	}
-	return nil
 }
 
-func validateRollup(n *Node) error {
+// collectNodeViolations runs the capability-independent rollup consistency
+// checks for a single node.
+func collectNodeViolations(c *violationCollector, n *Node) {
 	r := n.Rollup
 	if r == nil {
-		return nil
+		return
 	}
 
 	if r.TotalFiles < n.FileCount {
-		return fmt.Errorf("%s: total_files < file_count", n.Path)
+		c.add(nodeViolation(n, "rollup.total_files.covers_file_count", SeverityError,
+			fmt.Errorf("total_files (%d) < file_count (%d)", r.TotalFiles, n.FileCount)))
 	}
 
 	if r.TotalDescendantDirs < n.DirectSubdirCount {
-		return fmt.Errorf("%s: total_descendant_dirs < direct_subdir_count", n.Path)
+		c.add(nodeViolation(n, "rollup.total_descendant_dirs.covers_subdirs", SeverityError,
+			fmt.Errorf("total_descendant_dirs (%d) < direct_subdir_count (%d)", r.TotalDescendantDirs, n.DirectSubdirCount)))
 	}
+
 	if r.Size.Percentiles != nil {
 	    p := r.Size.Percentiles
 
 	    if r.Size.Median != p.P50 {
-	        return fmt.Errorf("%s: median != p50", n.Path)
+	        c.add(nodeViolation(n, "rollup.median.matches_p50", SeverityError,
+	            fmt.Errorf("median (%d) != p50 (%d)", r.Size.Median, p.P50)))
 	    }
 
 	    if !(r.Size.Min <= p.P50 &&
 	        p.P50 <= p.P90 &&
 	        p.P90 <= p.P99 &&
 	        p.P99 <= r.Size.Max) {
-	        return fmt.Errorf("%s: percentile ordering violated", n.Path)
+	        c.add(nodeViolation(n, "rollup.percentiles.ordering", SeverityError,
+	            fmt.Errorf("percentile ordering violated")))
 	    }
 	}
-
-	return nil
 }
 
+func nodeViolation(n *Node, invariant string, sev Severity, err error) InvariantViolation {
+	return InvariantViolation{
+		Path:       n.Path,
+		Capability: "rollup",
+		Invariant:  invariant,
+		Severity:   sev,
+		Err:        err,
+	}
+}
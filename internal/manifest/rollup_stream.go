@@ -0,0 +1,100 @@
+package manifest
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DirRollup is one directory's rollup as produced by RollupBuilder.
+type DirRollup struct {
+	Path              string  `json:"path" yaml:"path"`
+	DirectSubdirCount int     `json:"direct_subdir_count,omitempty" yaml:"direct_subdir_count,omitempty"`
+	Rollup            *Rollup `json:"rollup" yaml:"rollup"`
+}
+
+// RollupBuilder computes the same rollups as BuildRollups from nodes fed in
+// walk order (each directory before its descendants, subtrees contiguous).
+// Only the directories on the current path and their direct children are
+// held, so whole-volume scans can be rolled up while streaming.
+type RollupBuilder struct {
+	opts  RollupOptions
+	stack []*openDir
+	done  []DirRollup
+}
+
+type openDir struct {
+	node *Node
+	kids []*Node
+}
+
+func NewRollupBuilder(opts RollupOptions) *RollupBuilder {
+	return &RollupBuilder{opts: opts}
+}
+
+// Capabilities returns what the rollups declare, as BuildRollups would.
+func (b *RollupBuilder) Capabilities() RollupCapabilities {
+	return rollupCapabilities(b.opts)
+}
+
+// Add records the next node of the walk. Directories are finalized once
+// the walk has left them; their Rollup and DirectSubdirCount are set then.
+func (b *RollupBuilder) Add(n *Node) {
+	if n.Path == "." {
+		// BuildRollups lists the root among its own children.
+		b.stack = append(b.stack, &openDir{node: n, kids: []*Node{n}})
+		return
+	}
+
+	parent := b.parentOf(n.Path)
+	for len(b.stack) > 0 && b.stack[len(b.stack)-1].node.Path != parent {
+		b.close()
+	}
+	if len(b.stack) > 0 {
+		top := b.stack[len(b.stack)-1]
+		top.kids = append(top.kids, n)
+	}
+
+	if n.IsDir {
+		b.stack = append(b.stack, &openDir{node: n})
+	}
+}
+
+// Finish finalizes the remaining directories and returns every rollup,
+// ordered by path.
+func (b *RollupBuilder) Finish() []DirRollup {
+	for len(b.stack) > 0 {
+		b.close()
+	}
+	sort.Slice(b.done, func(i, j int) bool { return b.done[i].Path < b.done[j].Path })
+	return b.done
+}
+
+func (b *RollupBuilder) close() {
+	d := b.stack[len(b.stack)-1]
+	b.stack = b.stack[:len(b.stack)-1]
+
+	count := 0
+	for _, k := range d.kids {
+		if k.IsDir {
+			count++
+		}
+	}
+
+	d.node.Rollup = computeRollup(d.kids, b.opts)
+	d.node.DirectSubdirCount = count
+	b.done = append(b.done, DirRollup{Path: d.node.Path, DirectSubdirCount: count, Rollup: d.node.Rollup})
+}
+
+// parentOf mirrors Manifest.ParentFunc using the open directories.
+func (b *RollupBuilder) parentOf(path string) string {
+	dir := filepath.Dir(path)
+	if trimmed, ok := strings.CutSuffix(dir, ArchiveSep); ok {
+		for _, d := range b.stack {
+			if d.node.Path == trimmed && d.node.Archive != "" {
+				return trimmed
+			}
+		}
+	}
+	return dir
+}
@@ -0,0 +1,31 @@
+package manifest
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// RootMeta describes one root of a multi-root manifest. Its nodes are
+// namespaced under Name ("services/api/main.go"); the "." node is a
+// synthetic directory whose rollup aggregates every root.
+type RootMeta struct {
+	Name    string      `json:"name" yaml:"name"`
+	Path    string      `json:"path" yaml:"path"`
+	Filters *FilterMeta `json:"filters,omitempty" yaml:"filters,omitempty"`
+}
+
+// RootOf returns the root a node path belongs to and the path relative to
+// that root. It reports false for single-root manifests and for the
+// synthetic "." node.
+func (m *Manifest) RootOf(path string) (RootMeta, string, bool) {
+	name, rel, _ := strings.Cut(path, string(filepath.Separator))
+	for _, r := range m.Roots {
+		if r.Name == name {
+			if rel == "" {
+				rel = "."
+			}
+			return r, rel, true
+		}
+	}
+	return RootMeta{}, "", false
+}
@@ -2,6 +2,7 @@ package manifest
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 )
 /*
@@ -40,6 +41,28 @@ Examples:
 - Extension counts missing for empty directories
 - A capability declared that is deprecated but still accepted
 - A capability whose invariants are partially satisfied
+
+---
+
+SeverityInfo
+
+An advisory observation. Nothing is wrong with the manifest; the check only
+points at something a human or tool may want to know about.
+Properties:
+
+- Never fails validation, in any mode
+- Safe to ignore
+- Useful for tuning configuration or spotting oddities
+
+Examples:
+- An invariant downgraded via severity_overrides to keep it visible but quiet
+
+---
+
+SeverityOff
+
+Not a reported severity. Only valid in severity overrides, where it disables
+an invariant entirely.
 */
 
 type Severity string
@@ -47,6 +70,10 @@ type Severity string
 const (
 	SeverityError   Severity = "error"
 	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+
+	// SeverityOff disables an invariant; only meaningful as an override.
+	SeverityOff Severity = "off"
 )
 
 func (s Severity) IsFatal() bool {
@@ -54,7 +81,7 @@ func (s Severity) IsFatal() bool {
 }
 
 func (s Severity) Valid() bool {
-	return s == SeverityError || s == SeverityWarning
+	return s == SeverityError || s == SeverityWarning || s == SeverityInfo
 }
 
 func LogViolation(logger *slog.Logger, v InvariantViolation) {
@@ -84,14 +111,29 @@ func (s Severity) LogLevel() slog.Level {
     }
 }
 
-/*
-Not used - for later if we want it
 func ParseSeverity(s string) (Severity, bool) {
 	switch Severity(s) {
-	case SeverityError, SeverityWarning:
+	case SeverityError, SeverityWarning, SeverityInfo, SeverityOff:
 		return Severity(s), true
 	default:
 		return "", false
 	}
 }
-*/
+
+// ParseSeverityOverrides converts a config map of invariant name to
+// severity string (error, warning, info, off) into typed overrides.
+func ParseSeverityOverrides(raw map[string]string) (map[string]Severity, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	overrides := make(map[string]Severity, len(raw))
+	for invariant, value := range raw {
+		sev, ok := ParseSeverity(value)
+		if !ok {
+			return nil, fmt.Errorf("invariant %s: invalid severity %q (expected error, warning, info or off)", invariant, value)
+		}
+		overrides[invariant] = sev
+	}
+	return overrides, nil
+}
@@ -0,0 +1,59 @@
+package manifest
+
+import "time"
+
+// NDJSON record types. A stream is one header, the nodes in walk order, the
+// skipped entries, and one trailer; every line carries a "type" field.
+const (
+	RecordHeader  = "header"
+	RecordNode    = "node"
+	RecordSkipped = "skipped"
+	RecordTrailer = "trailer"
+)
+
+// StreamHeader is the first record: everything in a Manifest except nodes
+// and skipped entries.
+type StreamHeader struct {
+	Type      string       `json:"type"`
+	Manifest  ManifestMeta `json:"manifest"`
+	Root      string       `json:"root"`
+	Roots     []RootMeta   `json:"roots,omitempty"`
+	Generated time.Time    `json:"generated_at"`
+	Filters   *FilterMeta  `json:"filters,omitempty"`
+	Archives  *ArchiveMeta `json:"archives,omitempty"`
+}
+
+// StreamNode is a node record. Rollups are not known until the walk has
+// left a directory, so they are carried by the trailer instead.
+type StreamNode struct {
+	Type string `json:"type"`
+	*Node
+}
+
+// StreamSkipped is a skipped-entry record.
+type StreamSkipped struct {
+	Type string `json:"type"`
+	SkippedEntry
+}
+
+// StreamTrailer is the last record. Its counts let readers detect a
+// truncated stream.
+type StreamTrailer struct {
+	Type    string      `json:"type"`
+	Nodes   int         `json:"nodes"`
+	Skipped int         `json:"skipped"`
+	Rollups []DirRollup `json:"rollups,omitempty"`
+}
+
+// NewStreamHeader returns the header record for m.
+func NewStreamHeader(m *Manifest) StreamHeader {
+	return StreamHeader{
+		Type:      RecordHeader,
+		Manifest:  m.Manifest,
+		Root:      m.Root,
+		Roots:     m.Roots,
+		Generated: m.Generated,
+		Filters:   m.Filters,
+		Archives:  m.Archives,
+	}
+}
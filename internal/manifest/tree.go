@@ -0,0 +1,192 @@
+package manifest
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TreeLayout is the schema.layout marker of tree-layout documents. Flat
+// documents leave the marker empty.
+const TreeLayout = "tree.v1"
+
+// TreeManifest is the tree layout of a manifest: directories hold their
+// children keyed by basename, and rollups sit on their directory. Nodes
+// whose parent is not in the manifest (normally just ".") are the top level
+// of Tree, keyed by their full path.
+type TreeManifest struct {
+	Manifest  ManifestMeta         `json:"manifest" yaml:"manifest"`
+	Root      string               `json:"root" yaml:"root"`
+	Roots     []RootMeta           `json:"roots,omitempty" yaml:"roots,omitempty"`
+	Generated time.Time            `json:"generated_at" yaml:"generated_at"`
+	Filters   *FilterMeta          `json:"filters,omitempty" yaml:"filters,omitempty"`
+	Archives  *ArchiveMeta         `json:"archives,omitempty" yaml:"archives,omitempty"`
+	Budget    *BudgetMeta          `json:"budget,omitempty" yaml:"budget,omitempty"`
+	Tree      map[string]*TreeNode `json:"tree" yaml:"tree"`
+	Skipped   []SkippedEntry       `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+}
+
+// TreeNode is a Node without its path, which is implied by its position.
+type TreeNode struct {
+	IsDir             bool                 `json:"is_dir,omitempty" yaml:"is_dir,omitempty"`
+	Inode             uint64               `json:"inode,omitempty" yaml:"inode,omitempty"`
+	MtimeUnix         int64                `json:"mtime_unix,omitempty" yaml:"mtime_unix,omitempty"`
+	SizeBytes         int64                `json:"size_bytes,omitempty" yaml:"size_bytes,omitempty"`
+	Hash              string               `json:"hash,omitempty" yaml:"hash,omitempty"`
+	Archive           string               `json:"archive,omitempty" yaml:"archive,omitempty"`
+	FileCount         int                  `json:"file_count,omitempty" yaml:"file_count,omitempty"`
+	DirectSubdirCount int                  `json:"direct_subdir_count,omitempty" yaml:"direct_subdir_count,omitempty"`
+	Rollup            *Rollup              `json:"rollup,omitempty" yaml:"rollup,omitempty"`
+	Children          map[string]*TreeNode `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
+// Tree converts m to the tree layout. Nodes are shared with m, so later
+// changes to either are not independent.
+func (m *Manifest) Tree() (*TreeManifest, error) {
+	t := &TreeManifest{
+		Manifest:  m.Manifest,
+		Root:      m.Root,
+		Roots:     m.Roots,
+		Generated: m.Generated,
+		Filters:   m.Filters,
+		Archives:  m.Archives,
+		Budget:    m.Budget,
+		Tree:      make(map[string]*TreeNode),
+		Skipped:   m.Skipped,
+	}
+	t.Manifest.Schema.Layout = TreeLayout
+
+	parentOf := m.ParentFunc()
+	byPath := make(map[string]*TreeNode, len(m.Nodes))
+	for _, n := range m.Nodes {
+		if _, dup := byPath[n.Path]; dup {
+			return nil, fmt.Errorf("duplicate node %q", n.Path)
+		}
+		byPath[n.Path] = &TreeNode{
+			IsDir:             n.IsDir,
+			Inode:             n.Inode,
+			MtimeUnix:         n.MtimeUnix,
+			SizeBytes:         n.SizeBytes,
+			Hash:              n.Hash,
+			Archive:           n.Archive,
+			FileCount:         n.FileCount,
+			DirectSubdirCount: n.DirectSubdirCount,
+			Rollup:            n.Rollup,
+		}
+	}
+
+	for _, n := range m.Nodes {
+		tn := byPath[n.Path]
+		parent, ok := byPath[parentOf(n.Path)]
+		if n.Path == "." || !ok {
+			t.Tree[n.Path] = tn
+			continue
+		}
+		if parent.Children == nil {
+			parent.Children = make(map[string]*TreeNode)
+		}
+		parent.Children[childKey(parentOf(n.Path), n.Path)] = tn
+	}
+	return t, nil
+}
+
+// Flatten converts t back to the flat layout. Nodes come out in walk order:
+// each directory before its children, siblings sorted by name, and the
+// roots of a multi-root manifest in their recorded order.
+func (t *TreeManifest) Flatten() *Manifest {
+	m := &Manifest{
+		Manifest:  t.Manifest,
+		Root:      t.Root,
+		Roots:     t.Roots,
+		Generated: t.Generated,
+		Filters:   t.Filters,
+		Archives:  t.Archives,
+		Budget:    t.Budget,
+		Nodes:     []*Node{},
+		Skipped:   t.Skipped,
+	}
+	m.Manifest.Schema.Layout = ""
+
+	var visit func(path string, tn *TreeNode)
+	visit = func(path string, tn *TreeNode) {
+		m.Nodes = append(m.Nodes, &Node{
+			Path:              path,
+			IsDir:             tn.IsDir,
+			Inode:             tn.Inode,
+			MtimeUnix:         tn.MtimeUnix,
+			SizeBytes:         tn.SizeBytes,
+			Hash:              tn.Hash,
+			Archive:           tn.Archive,
+			FileCount:         tn.FileCount,
+			DirectSubdirCount: tn.DirectSubdirCount,
+			Rollup:            tn.Rollup,
+		})
+
+		keys := sortedKeys(tn.Children)
+		if path == "." && len(t.Roots) > 0 {
+			keys = rootsFirst(keys, t.Roots)
+		}
+		for _, k := range keys {
+			visit(ChildPath(path, tn.Archive != "", k), tn.Children[k])
+		}
+	}
+
+	for _, k := range sortedKeys(t.Tree) {
+		visit(k, t.Tree[k])
+	}
+	return m
+}
+
+// childKey is the key a node is stored under in its parent's children.
+func childKey(parent, path string) string {
+	if parent == "." {
+		return path
+	}
+	rest := strings.TrimPrefix(path, parent)
+	rest = strings.TrimPrefix(rest, ArchiveSep)
+	return strings.TrimPrefix(rest, string(filepath.Separator))
+}
+
+// ChildPath is the path of the child stored under key in the children of
+// the tree node at parent; archive reports whether that node is an archive.
+func ChildPath(parent string, archive bool, key string) string {
+	switch {
+	case parent == ".":
+		return key
+	case archive:
+		return parent + ArchiveSep + string(filepath.Separator) + key
+	default:
+		return parent + string(filepath.Separator) + key
+	}
+}
+
+func sortedKeys(children map[string]*TreeNode) []string {
+	keys := make([]string, 0, len(children))
+	for k := range children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// rootsFirst orders the top-level directories of a multi-root manifest as
+// the roots were scanned.
+func rootsFirst(keys []string, roots []RootMeta) []string {
+	rank := make(map[string]int, len(roots))
+	for i, r := range roots {
+		rank[r.Name] = i
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		ri, iok := rank[keys[i]]
+		rj, jok := rank[keys[j]]
+		switch {
+		case iok && jok:
+			return ri < rj
+		default:
+			return iok && !jok
+		}
+	})
+	return keys
+}
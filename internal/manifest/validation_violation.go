@@ -1,5 +1,11 @@
 package manifest
 
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
 type InvariantViolation struct {
 	Path        string
 	Capability  string
@@ -13,56 +19,167 @@ func (v InvariantViolation) IsFatal() bool {
 	return v.Severity.IsFatal()
 }
 
-// Validate evaluates all declared capability invariants.
+// Checker contributes violations beyond the built-in capability invariants,
+// e.g. user-defined repository policies. Its violations go through the same
+// severity resolution and limit as everything else.
+type Checker interface {
+	Check(m *Manifest) []InvariantViolation
+}
+
+// ValidationReport is the full result of a Validate run. Baselined
+// violations are counted in the summary but not listed.
+type ValidationReport struct {
+	Violations []InvariantViolation
+	Summary    ViolationSummary
+
+	// Truncated is set when violations past ValidateOptions.MaxViolations
+	// were dropped from Violations. Every check still runs and Summary
+	// counts the dropped violations too.
+	Truncated bool
+}
+
+// Validate evaluates all declared capability invariants and node-level
+// rollup checks, collecting every violation instead of stopping at the first.
 //
 // It returns:
-//   - all invariant violations (fatal + warnings)
+//   - a report with all invariant violations (fatal + warnings) and their summary
 //   - a non-nil error if at least one fatal violation occurred
 //
 // Callers may:
-//   - inspect violations for reporting or diagnostics
+//   - inspect the report for reporting or diagnostics
 //   - treat error as "manifest is invalid"
-func (m *Manifest) Validate(opts ValidateOptions) ([]InvariantViolation, error) {
-	var allViolations []InvariantViolation
+func (m *Manifest) Validate(opts ValidateOptions) (*ValidationReport, error) {
+	c := newViolationCollector(opts)
 
 	// --- Capability-driven invariant validation ---
-	capViolations, err := m.ValidateCapabilities()
-	allViolations = append(allViolations, capViolations...)
-	if err != nil {
-		return allViolations, err
-	}
+	m.collectRollupCapabilityViolations(c)
 
-	// --- Node-level validation (existing logic) ---
+	// --- Node-level validation ---
 	for _, n := range m.Nodes {
-		if err := validateNode(n, opts); err != nil {
-			return allViolations, err
+		collectNodeViolations(c, n)
+	}
+
+	// --- Additional checkers (policies) ---
+	for _, chk := range opts.Checkers {
+		for _, v := range chk.Check(m) {
+			c.add(v)
 		}
 	}
 
-	return allViolations, nil
+	report := &ValidationReport{
+		Violations: c.violations,
+		Summary:    c.summary,
+		Truncated:  c.truncated,
+	}
+	report.Summary.Baselined = c.baselined
+	report.Summary.Fixed = c.baseline.fixed()
+
+	err := fatalError(report.Violations)
+	if err == nil && report.Summary.Errors > 0 {
+		// Every error was dropped at the limit; the manifest is still invalid.
+		err = fmt.Errorf("%d error violations past the limit of %d", report.Summary.Errors, c.limit)
+	}
+	return report, err
 }
 
 // Fatal violations are returned as the error value.
 // Warnings are included in the violations slice only.
 func (m *Manifest) ValidateCapabilities() ([]InvariantViolation, error) {
-	violations := m.collectRollupCapabilityViolations()
+	c := newViolationCollector(ValidateOptions{Strict: true})
+	m.collectRollupCapabilityViolations(c)
+
+	return c.violations, fatalError(c.violations)
+}
 
+// fatalError joins every fatal violation into a single error, or returns nil.
+func fatalError(violations []InvariantViolation) error {
+	var errs []error
 	for _, v := range violations {
-		if v.Severity.IsFatal() {
-			return violations, v
+		if v.IsFatal() {
+			errs = append(errs, v)
 		}
 	}
+	return errors.Join(errs...)
+}
+
+// violationCollector accumulates violations up to an optional limit,
+// resolving each violation's effective severity on the way in.
+type violationCollector struct {
+	limit      int // 0 = unlimited
+	strict     bool
+	overrides  map[string]Severity
+	baseline   *baselineMatcher
+	violations []InvariantViolation
+	baselined  int
+	truncated  bool
+
+	// summary counts every reported violation, kept or not.
+	summary ViolationSummary
+}
+
+func newViolationCollector(opts ValidateOptions) *violationCollector {
+	return &violationCollector{
+		limit:     opts.MaxViolations,
+		strict:    opts.Strict,
+		overrides: opts.SeverityOverrides,
+		baseline:  newBaselineMatcher(opts.Baseline),
+		summary:   newViolationSummary(),
+	}
+}
 
-	return violations, nil
+// severity returns the effective severity for a violation, or SeverityOff
+// if it should not be reported.
+func (c *violationCollector) severity(v InvariantViolation) Severity {
+	if sev, ok := c.overrides[v.Invariant]; ok {
+		return sev
+	}
+
+	sev := v.Severity
+	if sev == "" {
+		// Invariants without an explicit severity have always been non-fatal.
+		sev = SeverityWarning
+	}
+	if !c.strict && sev == SeverityError {
+		sev = SeverityWarning
+	}
+	return sev
+}
+
+func (c *violationCollector) full() bool {
+	return c.limit > 0 && len(c.violations) >= c.limit
 }
 
-func (m *Manifest) collectRollupCapabilityViolations() []InvariantViolation {
-	var violations []InvariantViolation
+func (c *violationCollector) add(v InvariantViolation) {
+	v.Severity = c.severity(v)
+	if v.Severity == SeverityOff {
+		return
+	}
+
+	if c.baseline.match(v) {
+		c.baselined++
+		return
+	}
+
+	c.summary.count(v)
+	if c.full() {
+		c.truncated = true
+		return
+	}
+	c.violations = append(c.violations, v)
+}
 
+func (m *Manifest) collectRollupCapabilityViolations(c *violationCollector) {
 	declared := m.Manifest.Capabilities.Rollup.Declared()
 
-	for capName, enabled := range declared {
-		if !enabled {
+	// Deterministic order so a violation limit always keeps the same subset.
+	names := make([]string, 0, len(declared))
+	for capName := range declared {
+		names = append(names, capName)
+	}
+	sort.Strings(names)
+
+	for _, capName := range names {
+		if !declared[capName] {
 			continue
 		}
 
@@ -75,7 +192,7 @@ func (m *Manifest) collectRollupCapabilityViolations() []InvariantViolation {
 		// A declared capability with no invariants is invalid.
 		// Capabilities are opt-in guarantees; zero invariants means no guarantee.
 		if len(invariants) == 0 {
-			violations = append(violations, InvariantViolation{
+			c.add(InvariantViolation{
 				Capability:  capName,
 				Invariant:   "capability.has_invariants",
 				Description: "declared capability has no invariants",
@@ -92,7 +209,7 @@ func (m *Manifest) collectRollupCapabilityViolations() []InvariantViolation {
 
 			for _, inv := range invariants {
 				if err := inv.Validate(n); err != nil {
-					violations = append(violations, InvariantViolation{
+					c.add(InvariantViolation{
 						Path:        n.Path,
 						Capability:  capName,
 						Invariant:   inv.Name,
@@ -104,7 +221,4 @@ func (m *Manifest) collectRollupCapabilityViolations() []InvariantViolation {
 			}
 		}
 	}
-
-	return violations
 }
-
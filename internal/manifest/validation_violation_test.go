@@ -0,0 +1,152 @@
+package manifest
+
+import (
+	"errors"
+	"testing"
+)
+
+func brokenSizeStatsManifest(t *testing.T) *Manifest {
+	t.Helper()
+
+	m := testManifestWithCapabilities(t, true)
+
+	// Two directories, each missing every size stat and with
+	// inconsistent node-level counts.
+	for _, p := range []string{"a", "b"} {
+		m.Nodes = append(m.Nodes, &Node{
+			Path:      p,
+			IsDir:     true,
+			FileCount: 5,
+			Rollup: &Rollup{
+				TotalFiles: 2,
+			},
+		})
+	}
+	return m
+}
+
+func TestValidate_CollectsAllViolations(t *testing.T) {
+	m := brokenSizeStatsManifest(t)
+
+	report, err := m.Validate(ValidateOptions{Strict: true})
+	if err == nil {
+		t.Fatalf("expected fatal error")
+	}
+
+	// 5 size_stats presence checks + 1 node-level check, per directory.
+	if len(report.Violations) != 12 {
+		t.Fatalf("expected 12 violations, got %d: %+v", len(report.Violations), report.Violations)
+	}
+
+	if report.Summary.Errors != 12 || report.Summary.ByCapability["rollup"] != 2 {
+		t.Fatalf("unexpected summary: %+v", report.Summary)
+	}
+
+	var v InvariantViolation
+	if !errors.As(err, &v) {
+		t.Fatalf("expected error to wrap InvariantViolation, got %T", err)
+	}
+}
+
+func TestValidate_MaxViolations(t *testing.T) {
+	m := brokenSizeStatsManifest(t)
+
+	report, err := m.Validate(ValidateOptions{Strict: true, MaxViolations: 3})
+	if err == nil {
+		t.Fatalf("expected fatal error")
+	}
+
+	if len(report.Violations) != 3 || !report.Truncated {
+		t.Fatalf("expected 3 truncated violations, got %d (truncated=%v)", len(report.Violations), report.Truncated)
+	}
+
+	// The summary still counts everything that was found.
+	if report.Summary.Total != 12 || report.Summary.Errors != 12 || report.Summary.ByCapability["rollup"] != 2 {
+		t.Fatalf("unexpected summary: %+v", report.Summary)
+	}
+}
+
+// The capability checks alone fill a limit of 10; the node-level
+// violations that follow must still mark the report truncated.
+func TestValidate_MaxViolationsReachedBeforeNodeChecks(t *testing.T) {
+	m := brokenSizeStatsManifest(t)
+
+	report, _ := m.Validate(ValidateOptions{Strict: true, MaxViolations: 10})
+	if len(report.Violations) != 10 || !report.Truncated {
+		t.Fatalf("expected 10 truncated violations, got %d (truncated=%v)", len(report.Violations), report.Truncated)
+	}
+
+	// A limit that fits every violation drops nothing.
+	report, _ = m.Validate(ValidateOptions{Strict: true, MaxViolations: 12})
+	if len(report.Violations) != 12 || report.Truncated {
+		t.Fatalf("expected 12 untruncated violations, got %d (truncated=%v)", len(report.Violations), report.Truncated)
+	}
+}
+
+func TestValidate_LenientDowngradesErrors(t *testing.T) {
+	m := brokenSizeStatsManifest(t)
+
+	report, err := m.Validate(ValidateOptions{Strict: false})
+	if err != nil {
+		t.Fatalf("lenient mode should not fail: %v", err)
+	}
+
+	if report.Summary.Errors != 0 || report.Summary.Warnings != 12 {
+		t.Fatalf("unexpected summary: %+v", report.Summary)
+	}
+}
+
+func TestValidate_SeverityOverrides(t *testing.T) {
+	m := brokenSizeStatsManifest(t)
+
+	overrides, err := ParseSeverityOverrides(map[string]string{
+		"size.total.present":                   "off",
+		"size.min.present":                     "info",
+		"rollup.total_files.covers_file_count": "error",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, _ := m.Validate(ValidateOptions{Strict: false, SeverityOverrides: overrides})
+
+	s := report.Summary
+	if s.Total != 10 || s.Errors != 2 || s.Infos != 2 || s.Warnings != 6 {
+		t.Fatalf("unexpected summary: %+v", s)
+	}
+}
+
+func TestParseSeverityOverrides_Invalid(t *testing.T) {
+	if _, err := ParseSeverityOverrides(map[string]string{"size.total.present": "fatal"}); err == nil {
+		t.Fatalf("expected error for unknown severity")
+	}
+}
+
+func TestValidate_Baseline(t *testing.T) {
+	m := brokenSizeStatsManifest(t)
+
+	first, _ := m.Validate(ValidateOptions{Strict: true})
+	baseline := NewBaseline(first.Violations)
+
+	// Fix directory "b"; "a" stays broken and is fully baselined.
+	m.Nodes[1].FileCount = 0
+	withSizeStats(m.Nodes[1].Rollup, 100, 10, 60, 33, 30)
+
+	report, err := m.Validate(ValidateOptions{Strict: true, Baseline: baseline})
+	if err != nil {
+		t.Fatalf("baselined violations should not be fatal: %v", err)
+	}
+
+	s := report.Summary
+	if s.Total != 0 || s.Baselined != 6 || s.Fixed != 6 {
+		t.Fatalf("unexpected summary: %+v", s)
+	}
+
+	// A new kind of violation on "b" is reported even though "b" had
+	// baselined violations before.
+	m.Nodes[1].Rollup.Size.Min = 70
+	report, err = m.Validate(ValidateOptions{Strict: true, Baseline: baseline})
+	if err == nil || report.Summary.Total != 1 {
+		t.Fatalf("expected 1 new violation, got %+v (err=%v)", report.Summary, err)
+	}
+}
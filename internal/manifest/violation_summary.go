@@ -3,9 +3,17 @@ package manifest
 import "log/slog"
 
 type ViolationSummary struct {
+	// Total, Errors, Warnings and Infos count new (not baselined)
+	// violations, including any dropped past a violation limit.
 	Total    int
 	Errors   int
 	Warnings int
+	Infos    int
+
+	// Baselined violations were suppressed by a baseline; Fixed baseline
+	// entries no longer occur.
+	Baselined int
+	Fixed     int
 
 	ByCapability map[string]int
 	ByInvariant  map[string]int
@@ -16,29 +24,38 @@ func (s ViolationSummary) HasErrors() bool {
 }
 
 func SummarizeViolations(violations []InvariantViolation) ViolationSummary {
-	s := ViolationSummary{
-		Total:        len(violations),
+	s := newViolationSummary()
+	for _, v := range violations {
+		s.count(v)
+	}
+	return s
+}
+
+func newViolationSummary() ViolationSummary {
+	return ViolationSummary{
 		ByCapability: make(map[string]int),
 		ByInvariant:  make(map[string]int),
 	}
+}
 
-	for _, v := range violations {
-		if v.Severity.IsFatal() {
-			s.Errors++
-		} else {
-			s.Warnings++
-		}
-
-		if v.Capability != "" {
-			s.ByCapability[v.Capability]++
-		}
-
-		if v.Invariant != "" {
-			s.ByInvariant[v.Invariant]++
-		}
+func (s *ViolationSummary) count(v InvariantViolation) {
+	s.Total++
+	switch v.Severity {
+	case SeverityError:
+		s.Errors++
+	case SeverityInfo:
+		s.Infos++
+	default:
+		s.Warnings++
 	}
 
-	return s
+	if v.Capability != "" {
+		s.ByCapability[v.Capability]++
+	}
+
+	if v.Invariant != "" {
+		s.ByInvariant[v.Invariant]++
+	}
 }
 
 func LogViolationSummary(logger *slog.Logger, s ViolationSummary) {
@@ -47,6 +64,9 @@ func LogViolationSummary(logger *slog.Logger, s ViolationSummary) {
 		slog.Int("total", s.Total),
 		slog.Int("errors", s.Errors),
 		slog.Int("warnings", s.Warnings),
+		slog.Int("infos", s.Infos),
+		slog.Int("baselined", s.Baselined),
+		slog.Int("fixed", s.Fixed),
 		slog.Any("by_capability", s.ByCapability),
 	)
 }
@@ -0,0 +1,36 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+	"gopkg.in/yaml.v3"
+)
+
+// WriteBaseline writes a violation baseline. JSON is used for .json files,
+// YAML otherwise.
+func WriteBaseline(path string, b *manifest.Baseline) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create baseline file: %w", err)
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(b)
+	} else {
+		enc := yaml.NewEncoder(f)
+		enc.SetIndent(2)
+		err = enc.Encode(b)
+	}
+	if err != nil {
+		return fmt.Errorf("encode baseline: %w", err)
+	}
+	return nil
+}
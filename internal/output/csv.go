@@ -0,0 +1,218 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+// CSVOptions controls the csv and tsv formats.
+type CSVOptions struct {
+	// Comma is the field delimiter (default ',').
+	Comma rune
+
+	// Columns and RollupColumns select and order the columns by name
+	// (default: DefaultCSVColumns and every rollup column).
+	Columns       []string
+	RollupColumns []string
+
+	// Headers renames columns in the header row, by column name.
+	Headers map[string]string
+}
+
+// csvRow is what a column is computed from.
+type csvRow struct {
+	n      *manifest.Node
+	parent string
+	depth  int
+}
+
+type csvColumn struct {
+	name  string
+	value func(csvRow) string
+}
+
+// DefaultCSVColumns are the node columns written when none are configured.
+var DefaultCSVColumns = []string{"path", "type", "size", "mtime", "inode", "extension", "depth", "parent"}
+
+var csvNodeColumns = []csvColumn{
+	{"path", func(r csvRow) string { return r.n.Path }},
+	{"name", func(r csvRow) string { return filepath.Base(r.n.Path) }},
+	{"type", func(r csvRow) string { return nodeType(r.n) }},
+	{"size", func(r csvRow) string { return itoa(r.n.SizeBytes) }},
+	{"mtime", func(r csvRow) string { return isoTime(r.n.MtimeUnix) }},
+	{"mtime_unix", func(r csvRow) string { return itoa(r.n.MtimeUnix) }},
+	{"inode", func(r csvRow) string { return strconv.FormatUint(r.n.Inode, 10) }},
+	{"extension", func(r csvRow) string {
+		if r.n.IsDir {
+			return ""
+		}
+		return filepath.Ext(r.n.Path)
+	}},
+	{"depth", func(r csvRow) string { return strconv.Itoa(r.depth) }},
+	{"parent", func(r csvRow) string { return r.parent }},
+	{"hash", func(r csvRow) string { return r.n.Hash }},
+	{"archive", func(r csvRow) string { return r.n.Archive }},
+	{"file_count", func(r csvRow) string { return strconv.Itoa(r.n.FileCount) }},
+	{"direct_subdir_count", func(r csvRow) string { return strconv.Itoa(r.n.DirectSubdirCount) }},
+}
+
+// csvRollupColumns flatten a rollup; empty cells mean the statistic was
+// not computed.
+var csvRollupColumns = []csvColumn{
+	{"path", func(r csvRow) string { return r.n.Path }},
+	{"total_files", func(r csvRow) string { return strconv.Itoa(r.n.Rollup.TotalFiles) }},
+	{"total_descendant_dirs", func(r csvRow) string { return strconv.Itoa(r.n.Rollup.TotalDescendantDirs) }},
+	{"size_total", func(r csvRow) string { return itoa(r.n.Rollup.Size.Total) }},
+	{"size_min", func(r csvRow) string { return itoa(r.n.Rollup.Size.Min) }},
+	{"size_max", func(r csvRow) string { return itoa(r.n.Rollup.Size.Max) }},
+	{"size_mean", func(r csvRow) string { return itoa(r.n.Rollup.Size.Mean) }},
+	{"size_median", func(r csvRow) string { return itoa(r.n.Rollup.Size.Median) }},
+	{"size_p50", percentile(func(p *manifest.Percentiles) int64 { return p.P50 })},
+	{"size_p90", percentile(func(p *manifest.Percentiles) int64 { return p.P90 })},
+	{"size_p99", percentile(func(p *manifest.Percentiles) int64 { return p.P99 })},
+	{"buckets_lt_1kb", bucket(func(b *manifest.SizeBuckets) int { return b.Lt1KB })},
+	{"buckets_kb_to_1mb", bucket(func(b *manifest.SizeBuckets) int { return b.KbTo1MB })},
+	{"buckets_mb_to_10mb", bucket(func(b *manifest.SizeBuckets) int { return b.MbTo10MB })},
+	{"buckets_gt_10mb", bucket(func(b *manifest.SizeBuckets) int { return b.Gt10MB })},
+	{"last_modified", func(r csvRow) string { return isoTime(r.n.Rollup.LastModified) }},
+	{"last_modified_unix", func(r csvRow) string { return itoa(r.n.Rollup.LastModified) }},
+}
+
+// EncodeCSV writes one row per node.
+func EncodeCSV(w io.Writer, m *manifest.Manifest, opts CSVOptions) error {
+	cols, err := selectColumns(csvNodeColumns, opts.Columns, DefaultCSVColumns)
+	if err != nil {
+		return err
+	}
+	return writeCSV(w, m, opts, cols, func(*manifest.Node) bool { return true })
+}
+
+// EncodeCSVRollups writes one row per directory rollup.
+func EncodeCSVRollups(w io.Writer, m *manifest.Manifest, opts CSVOptions) error {
+	cols, err := selectColumns(csvRollupColumns, opts.RollupColumns, nil)
+	if err != nil {
+		return err
+	}
+	return writeCSV(w, m, opts, cols, func(n *manifest.Node) bool { return n.Rollup != nil })
+}
+
+// CheckCSVColumns reports unknown column names before anything is written.
+func CheckCSVColumns(opts CSVOptions) error {
+	if _, err := selectColumns(csvNodeColumns, opts.Columns, DefaultCSVColumns); err != nil {
+		return err
+	}
+	_, err := selectColumns(csvRollupColumns, opts.RollupColumns, nil)
+	return err
+}
+
+func writeCSV(w io.Writer, m *manifest.Manifest, opts CSVOptions, cols []csvColumn, include func(*manifest.Node) bool) error {
+	cw := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		cw.Comma = opts.Comma
+	}
+
+	record := make([]string, len(cols))
+	for i, c := range cols {
+		record[i] = c.name
+		if h, ok := opts.Headers[c.name]; ok {
+			record[i] = h
+		}
+	}
+	if err := cw.Write(record); err != nil {
+		return fmt.Errorf("write csv: %w", err)
+	}
+
+	idx := newNodeIndex(m)
+	for _, n := range m.Nodes {
+		if !include(n) {
+			continue
+		}
+		row := csvRow{n: n, parent: idx.parent[n.Path], depth: idx.depth[n.Path]}
+		for i, c := range cols {
+			record[i] = c.value(row)
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("write csv: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("write csv: %w", err)
+	}
+	return nil
+}
+
+// selectColumns picks names from all in order; no names selects defaults,
+// or every column when defaults is nil.
+func selectColumns(all []csvColumn, names, defaults []string) ([]csvColumn, error) {
+	if len(names) == 0 {
+		names = defaults
+	}
+	if len(names) == 0 {
+		return all, nil
+	}
+
+	byName := make(map[string]csvColumn, len(all))
+	known := make([]string, len(all))
+	for i, c := range all {
+		byName[c.name] = c
+		known[i] = c.name
+	}
+
+	cols := make([]csvColumn, len(names))
+	for i, name := range names {
+		c, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown csv column %q (available: %s)", name, strings.Join(known, ", "))
+		}
+		cols[i] = c
+	}
+	return cols, nil
+}
+
+func nodeType(n *manifest.Node) string {
+	switch {
+	case n.Archive != "":
+		return "archive"
+	case n.IsDir:
+		return "dir"
+	default:
+		return "file"
+	}
+}
+
+func percentile(field func(*manifest.Percentiles) int64) func(csvRow) string {
+	return func(r csvRow) string {
+		if p := r.n.Rollup.Size.Percentiles; p != nil {
+			return itoa(field(p))
+		}
+		return ""
+	}
+}
+
+func bucket(field func(*manifest.SizeBuckets) int) func(csvRow) string {
+	return func(r csvRow) string {
+		if b := r.n.Rollup.Size.Buckets; b != nil {
+			return strconv.Itoa(field(b))
+		}
+		return ""
+	}
+}
+
+func itoa(n int64) string { return strconv.FormatInt(n, 10) }
+
+// isoTime formats a Unix time for spreadsheets; 0 means unknown.
+func isoTime(unix int64) string {
+	if unix == 0 {
+		return ""
+	}
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,71 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+func csvFixture(t *testing.T) *manifest.Manifest {
+	t.Helper()
+
+	m := &manifest.Manifest{
+		Root: "repo",
+		Nodes: []*manifest.Node{
+			{Path: ".", IsDir: true},
+			{Path: "README.md", SizeBytes: 100, MtimeUnix: 1767225600},
+			{Path: "src", IsDir: true},
+			{Path: "src/a.go", SizeBytes: 2048, Inode: 7},
+		},
+	}
+	if err := m.BuildRollups(manifest.RollupOptions{EnableSizeBytes: true, EnablePercentiles: true}); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestEncodeCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeCSV(&buf, csvFixture(t), CSVOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "path,type,size,mtime,inode,extension,depth,parent\n" +
+		".,dir,0,,0,,0,\n" +
+		"README.md,file,100,2026-01-01T00:00:00Z,0,.md,1,.\n" +
+		"src,dir,0,,0,,1,.\n" +
+		"src/a.go,file,2048,,7,.go,2,src\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestEncodeCSVColumnsAndHeaders(t *testing.T) {
+	opts := CSVOptions{
+		Comma:         '\t',
+		Columns:       []string{"name", "size"},
+		RollupColumns: []string{"path", "size_total", "size_p90", "buckets_gt_10mb"},
+		Headers:       map[string]string{"size": "Size (bytes)", "size_total": "Total"},
+	}
+
+	var nodes, rollups bytes.Buffer
+	if err := EncodeCSV(&nodes, csvFixture(t), opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := EncodeCSVRollups(&rollups, csvFixture(t), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "name\tSize (bytes)\n.\t0\nREADME.md\t100\nsrc\t0\na.go\t2048\n"; nodes.String() != want {
+		t.Errorf("nodes:\n%s\nwant:\n%s", nodes.String(), want)
+	}
+	// Buckets were not computed, so their cells are empty.
+	if want := "path\tTotal\tsize_p90\tbuckets_gt_10mb\n.\t100\t100\t\nsrc\t2048\t2048\t\n"; rollups.String() != want {
+		t.Errorf("rollups:\n%s\nwant:\n%s", rollups.String(), want)
+	}
+
+	if err := CheckCSVColumns(CSVOptions{Columns: []string{"path", "owner"}}); err == nil {
+		t.Error("unknown column accepted")
+	}
+}
@@ -0,0 +1,79 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dtnitsch/manifestor/internal/diff"
+	"gopkg.in/yaml.v3"
+)
+
+// WriteChangeSet writes a manifest change set as text, json or yaml.
+func WriteChangeSet(w io.Writer, format string, cs *diff.ChangeSet) error {
+	var err error
+
+	switch format {
+	case "text":
+		err = writeChangeSetText(w, cs)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(cs)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		err = enc.Encode(cs)
+	default:
+		return fmt.Errorf("unsupported diff format: %s (supported: text, json, yaml)", format)
+	}
+
+	if err != nil {
+		return fmt.Errorf("encode change set: %w", err)
+	}
+	return nil
+}
+
+func writeChangeSetText(w io.Writer, cs *diff.ChangeSet) error {
+	var b strings.Builder
+
+	for _, n := range cs.Added {
+		fmt.Fprintf(&b, "+ %s%s\n", n.Path, dirSuffix(n.IsDir))
+	}
+	for _, n := range cs.Removed {
+		fmt.Fprintf(&b, "- %s%s\n", n.Path, dirSuffix(n.IsDir))
+	}
+	for _, mv := range cs.Moved {
+		fmt.Fprintf(&b, "> %s%s -> %s (by %s)\n", mv.From, dirSuffix(mv.IsDir), mv.To, mv.By)
+	}
+	for _, mod := range cs.Modified {
+		parts := make([]string, len(mod.Changes))
+		for i, c := range mod.Changes {
+			parts[i] = fmt.Sprintf("%s %s -> %s", c.Field, c.Old, c.New)
+		}
+		fmt.Fprintf(&b, "~ %s (%s)\n", mod.Path, strings.Join(parts, ", "))
+	}
+
+	if len(cs.Rollups) > 0 {
+		b.WriteString("\nrollups:\n")
+		for _, r := range cs.Rollups {
+			fmt.Fprintf(&b, "  %s/ files %+d, dirs %+d, size %d -> %d (%+d bytes)\n",
+				r.Path, r.FilesDelta, r.DirsDelta, r.SizeOld, r.SizeNew, r.SizeDelta)
+		}
+	}
+
+	s := cs.Summary
+	fmt.Fprintf(&b, "\n%d added, %d removed, %d modified, %d moved, %+d bytes\n",
+		s.Added, s.Removed, s.Modified, s.Moved, s.SizeDelta)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dirSuffix(isDir bool) string {
+	if isDir {
+		return "/"
+	}
+	return ""
+}
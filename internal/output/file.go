@@ -0,0 +1,82 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dtnitsch/manifestor/internal/compress"
+)
+
+// Stdout is the output path that writes to standard output.
+const Stdout = "-"
+
+// Create opens path for writing, or standard output for Stdout. The output
+// is compressed as named (gzip, zstd or none); "" compresses by the path's
+// extension. Close flushes the compressor and closes the file.
+func Create(path, compression string) (io.WriteCloser, error) {
+	if compression == "" {
+		compression = compress.FromPath(path)
+	}
+
+	var f io.WriteCloser = os.Stdout
+	if path != Stdout {
+		file, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("create output file: %w", err)
+		}
+		f = file
+	}
+
+	zw, err := compress.NewWriter(f, compression)
+	if err != nil {
+		if path != Stdout {
+			f.Close()
+		}
+		return nil, err
+	}
+	return &outputFile{w: zw, f: f, stdout: path == Stdout}, nil
+}
+
+type outputFile struct {
+	w      io.WriteCloser
+	f      io.Closer
+	stdout bool
+	closed bool
+}
+
+func (o *outputFile) Write(p []byte) (int, error) { return o.w.Write(p) }
+
+// Close is safe to call again, e.g. from a deferred Close after an
+// explicit one.
+func (o *outputFile) Close() error {
+	if o.closed {
+		return nil
+	}
+	o.closed = true
+
+	err := o.w.Close()
+	if !o.stdout {
+		if cerr := o.f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("close output file: %w", err)
+	}
+	return nil
+}
+
+// writeFile creates path with Create and closes it after encode, reporting
+// the first error.
+func writeFile(path string, encode func(io.Writer) error) error {
+	f, err := Create(path, "")
+	if err != nil {
+		return err
+	}
+	if err := encode(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,235 @@
+package output
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+// HTMLOptions controls the html format.
+type HTMLOptions struct {
+	// Validation is shown in the report; nil means validation did not run.
+	Validation *manifest.ValidationReport
+}
+
+//go:embed report.html
+var reportTemplate string
+
+var reportHTML = template.Must(template.New("report").Funcs(template.FuncMap{
+	"bytes": formatBytes,
+	"date": func(unix int64) string {
+		if unix == 0 {
+			return ""
+		}
+		return time.Unix(unix, 0).UTC().Format("2006-01-02")
+	},
+	"pct": func(part, total int64) string {
+		if total == 0 {
+			return "0.0%"
+		}
+		return fmt.Sprintf("%.1f%%", 100*float64(part)/float64(total))
+	},
+}).Parse(reportTemplate))
+
+// treemapFiles is how many of a directory's largest files get their own
+// treemap box; the rest share one.
+const treemapFiles = 20
+
+// EncodeHTML writes a self-contained HTML report: a treemap of directory
+// sizes, a sortable directory table, an extension breakdown, the skipped
+// entries and the validation results. It loads nothing from the network.
+func EncodeHTML(w io.Writer, m *manifest.Manifest, opts HTMLOptions) error {
+	t, err := m.Tree()
+	if err != nil {
+		return fmt.Errorf("html report: %w", err)
+	}
+
+	r := &htmlReport{
+		Root:       m.Root,
+		Skipped:    m.Skipped,
+		Validation: opts.Validation,
+		sizeStats:  m.Manifest.Capabilities.Rollup.SizeStats,
+		collapsed:  make(map[string]manifest.CollapsedDir),
+		exts:       make(map[string]*extRow),
+	}
+	if !m.Generated.IsZero() {
+		r.Generated = m.Generated.UTC().Format(time.RFC3339)
+	}
+	if m.Budget != nil {
+		for _, c := range m.Budget.Collapsed {
+			r.collapsed[c.Path] = c
+		}
+	}
+
+	root := &treemapNode{Name: m.Root, Path: "."}
+	for _, k := range childKeys(&manifest.TreeNode{Children: t.Tree}) {
+		if n, _ := r.visit(k, k, t.Tree[k]); n != nil {
+			root.Children = append(root.Children, n)
+		}
+	}
+	// A single top-level directory (normally ".") is the treemap's root.
+	if len(root.Children) == 1 && root.Children[0].Path == "." {
+		root = root.Children[0]
+		root.Name = m.Root
+	} else {
+		for _, c := range root.Children {
+			root.Size += c.Size
+			root.Files += c.Files
+		}
+	}
+	r.Tree = root
+	r.TotalBytes, r.TotalFiles = root.Size, root.Files
+
+	sort.SliceStable(r.Dirs, func(i, j int) bool { return r.Dirs[i].Size > r.Dirs[j].Size })
+	for _, e := range r.exts {
+		r.Extensions = append(r.Extensions, *e)
+	}
+	sort.Slice(r.Extensions, func(i, j int) bool {
+		a, b := r.Extensions[i], r.Extensions[j]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return a.Ext < b.Ext
+	})
+
+	if err := reportHTML.Execute(w, r); err != nil {
+		return fmt.Errorf("html report: %w", err)
+	}
+	return nil
+}
+
+type htmlReport struct {
+	Root       string
+	Generated  string
+	TotalBytes int64
+	TotalFiles int
+	Tree       *treemapNode
+	Dirs       []dirRow
+	Extensions []extRow
+	Skipped    []manifest.SkippedEntry
+	Validation *manifest.ValidationReport
+
+	sizeStats bool
+	collapsed map[string]manifest.CollapsedDir
+	exts      map[string]*extRow
+}
+
+// treemapNode is the treemap's data, embedded as JSON with short keys.
+type treemapNode struct {
+	Name     string         `json:"n"`
+	Path     string         `json:"p,omitempty"`
+	Size     int64          `json:"s"`
+	Files    int            `json:"f,omitempty"`
+	Children []*treemapNode `json:"c,omitempty"`
+}
+
+type dirRow struct {
+	Path         string
+	Files        int
+	Dirs         int
+	Size         int64
+	DirectSize   int64
+	LastModified int64
+	Collapsed    bool
+}
+
+type extRow struct {
+	Ext   string
+	Files int
+	Bytes int64
+}
+
+// visit builds the treemap node and table row of a directory; files
+// return nil and are counted by their directory.
+func (r *htmlReport) visit(path, name string, n *manifest.TreeNode) (*treemapNode, *dirRow) {
+	if !n.IsDir && len(n.Children) == 0 {
+		r.countFile(name, n.SizeBytes)
+		return nil, nil
+	}
+
+	node := &treemapNode{Name: name, Path: path}
+	row := dirRow{Path: path}
+
+	var files []*treemapNode
+	var direct int64
+	for _, k := range childKeys(n) {
+		c, childPath := n.Children[k], manifest.ChildPath(path, n.Archive != "", k)
+		if child, childRow := r.visit(childPath, k, c); child != nil {
+			node.Children = append(node.Children, child)
+			node.Size += child.Size
+			node.Files += child.Files
+			row.Dirs += 1 + childRow.Dirs
+			row.LastModified = max(row.LastModified, childRow.LastModified)
+			continue
+		}
+		files = append(files, &treemapNode{Name: k, Path: childPath, Size: c.SizeBytes, Files: 1})
+		direct += c.SizeBytes
+		row.LastModified = max(row.LastModified, c.MtimeUnix)
+	}
+
+	// Rollup.Size.Total is the directory's own files, when sizes were rolled up.
+	if n.Rollup != nil && r.sizeStats {
+		direct = n.Rollup.Size.Total
+		row.LastModified = max(row.LastModified, n.Rollup.LastModified)
+	}
+	if c, ok := r.collapsed[path]; ok {
+		// Only the summary of a collapsed subtree is left.
+		direct, row.Collapsed = c.SizeBytes, true
+		files = []*treemapNode{{Name: fmt.Sprintf("(%d files, collapsed)", c.Files), Size: c.SizeBytes, Files: c.Files}}
+		row.Dirs += c.Dirs
+		row.LastModified = max(row.LastModified, c.LastModified)
+		// Per-extension bytes were not kept, so the subtree gets its own row.
+		e := r.ext("(collapsed)")
+		e.Files += c.Files
+		e.Bytes += c.SizeBytes
+	}
+
+	node.Children = append(node.Children, groupFiles(files)...)
+	node.Size += direct
+	for _, f := range files {
+		node.Files += f.Files
+	}
+
+	row.Size, row.DirectSize, row.Files = node.Size, direct, node.Files
+	r.Dirs = append(r.Dirs, row)
+	return node, &row
+}
+
+// groupFiles keeps the largest files and merges the rest into one box.
+func groupFiles(files []*treemapNode) []*treemapNode {
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Size > files[j].Size })
+	if len(files) <= treemapFiles {
+		return files
+	}
+
+	rest := &treemapNode{Name: fmt.Sprintf("(%d smaller files)", len(files)-treemapFiles)}
+	for _, f := range files[treemapFiles:] {
+		rest.Size += f.Size
+		rest.Files += f.Files
+	}
+	return append(files[:treemapFiles:treemapFiles], rest)
+}
+
+func (r *htmlReport) countFile(name string, size int64) {
+	e := r.ext(filepath.Ext(name))
+	e.Files++
+	e.Bytes += size
+}
+
+func (r *htmlReport) ext(ext string) *extRow {
+	if ext == "" {
+		ext = "(none)"
+	}
+	e, ok := r.exts[ext]
+	if !ok {
+		e = &extRow{Ext: ext}
+		r.exts[ext] = e
+	}
+	return e
+}
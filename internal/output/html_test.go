@@ -0,0 +1,127 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+func TestEncodeHTML(t *testing.T) {
+	m := csvFixture(t)
+	m.Skipped = []manifest.SkippedEntry{{Path: "node_modules", IsDir: true, Reason: "excluded", Rule: "node_modules"}}
+
+	var buf bytes.Buffer
+	if err := EncodeHTML(&buf, m, HTMLOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	// Self-contained: no external scripts, styles or images.
+	if ext := regexp.MustCompile(`(?i)(src|href)\s*=\s*["']?(https?:)?//`).FindString(out); ext != "" {
+		t.Errorf("report loads an external resource: %s", ext)
+	}
+
+	for _, want := range []string{
+		"<code>src</code>",          // directory table
+		"<code>.go</code>",          // extension table
+		"<code>node_modules</code>", // skipped entries
+		"Validation did not run.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report is missing %q", want)
+		}
+	}
+
+	data := regexp.MustCompile(`(?s)<script type="application/json" id="tree">(.*?)</script>`).FindStringSubmatch(out)
+	if data == nil {
+		t.Fatal("report has no treemap data")
+	}
+	var tree treemapNode
+	if err := json.Unmarshal([]byte(data[1]), &tree); err != nil {
+		t.Fatalf("treemap data: %v", err)
+	}
+	if tree.Name != "repo" || tree.Size != 2148 || tree.Files != 2 {
+		t.Errorf("treemap root = %+v, want repo with 2148 bytes in 2 files", tree)
+	}
+	if len(tree.Children) != 2 || tree.Children[0].Name != "src" || tree.Children[0].Size != 2048 {
+		t.Errorf("treemap children = %+v, want src (2048) and README.md", tree.Children)
+	}
+}
+
+func TestEncodeHTMLValidation(t *testing.T) {
+	report := &manifest.ValidationReport{
+		Violations: []manifest.InvariantViolation{{
+			Path:        "src",
+			Capability:  "policy",
+			Invariant:   "no-<script>",
+			Description: "forbidden file",
+			Severity:    manifest.SeverityError,
+		}},
+		Summary: manifest.ViolationSummary{Total: 1, Errors: 1},
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeHTML(&buf, csvFixture(t), HTMLOptions{Validation: report}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{"1 errors", `class="sev-error"`, "forbidden file", "no-&lt;script&gt;"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report is missing %q", want)
+		}
+	}
+	if strings.Contains(out, "Validation did not run.") {
+		t.Error("report says validation did not run")
+	}
+}
+
+func TestEncodeHTMLCollapsed(t *testing.T) {
+	m := &manifest.Manifest{
+		Root: "repo",
+		Nodes: []*manifest.Node{
+			{Path: ".", IsDir: true},
+			{Path: "src", IsDir: true},
+			{Path: "src/a.go", SizeBytes: 2048},
+			{Path: "vendor", IsDir: true},
+		},
+		Budget: &manifest.BudgetMeta{Collapsed: []manifest.CollapsedDir{{
+			Path:       "vendor",
+			Files:      3,
+			Dirs:       1,
+			SizeBytes:  9000,
+			Extensions: map[string]int{".go": 2, ".md": 1},
+		}}},
+	}
+	if err := m.BuildRollups(manifest.RollupOptions{EnableSizeBytes: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeHTML(&buf, m, HTMLOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	row := func(ext string) string {
+		m := regexp.MustCompile(`<td><code>` + regexp.QuoteMeta(ext) + `</code></td>\s*<td class="num">(\d+)</td>\s*<td class="num" data-v="(\d+)">`).FindStringSubmatch(out)
+		if m == nil {
+			return ""
+		}
+		return m[1] + " files, " + m[2] + " bytes"
+	}
+	// The collapsed files are counted once, with their bytes, in their own row.
+	if got := row("(collapsed)"); got != "3 files, 9000 bytes" {
+		t.Errorf("(collapsed) row = %q", got)
+	}
+	if got := row(".go"); got != "1 files, 2048 bytes" {
+		t.Errorf(".go row = %q", got)
+	}
+	if got := row(".md"); got != "" {
+		t.Errorf(".md row = %q, want none", got)
+	}
+}
@@ -3,24 +3,22 @@ package output
 import (
     "encoding/json"
     "fmt"
-    "os"
-
-    "github.com/dtnitsch/manifestor/internal/manifest"
+    "io"
 )
 
-func WriteJSON(path string, m *manifest.Manifest) error {
-    f, err := os.Create(path)
-    if err != nil {
-        return fmt.Errorf("create output file: %w", err)
-    }
-    defer f.Close()
+// WriteJSON writes a manifest document, either a *manifest.Manifest or its
+// *manifest.TreeManifest layout, to path as Create opens it.
+func WriteJSON(path string, doc any) error {
+    return writeFile(path, func(w io.Writer) error { return EncodeJSON(w, doc) })
+}
 
-    enc := json.NewEncoder(f)
+// EncodeJSON is WriteJSON for an open writer.
+func EncodeJSON(w io.Writer, doc any) error {
+    enc := json.NewEncoder(w)
     enc.SetIndent("", "  ")
 
-    if err := enc.Encode(m); err != nil {
+    if err := enc.Encode(doc); err != nil {
         return fmt.Errorf("encode manifest: %w", err)
     }
     return nil
 }
-
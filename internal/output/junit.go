@@ -0,0 +1,125 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteViolationsJUnit writes a validation report as JUnit XML. Each
+// capability becomes a test suite and each violating node a test case.
+// Error violations are failures; warnings and info are kept as output on
+// an otherwise passing case.
+func WriteViolationsJUnit(w io.Writer, source string, r *manifest.ValidationReport) error {
+	type caseKey struct{ capability, path string }
+
+	byCase := make(map[caseKey][]manifest.InvariantViolation)
+	var keys []caseKey
+	for _, v := range r.Violations {
+		k := caseKey{v.Capability, v.Path}
+		if _, ok := byCase[k]; !ok {
+			keys = append(keys, k)
+		}
+		byCase[k] = append(byCase[k], v)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].capability != keys[j].capability {
+			return keys[i].capability < keys[j].capability
+		}
+		return keys[i].path < keys[j].path
+	})
+
+	doc := junitTestSuites{Name: source}
+	suiteIndex := make(map[string]int)
+
+	for _, k := range keys {
+		idx, ok := suiteIndex[k.capability]
+		if !ok {
+			idx = len(doc.Suites)
+			suiteIndex[k.capability] = idx
+			doc.Suites = append(doc.Suites, junitTestSuite{Name: k.capability})
+		}
+		suite := &doc.Suites[idx]
+
+		name := k.path
+		if name == "" {
+			name = source
+		}
+		tc := junitTestCase{Name: name, ClassName: k.capability}
+
+		var failures, notes []string
+		for _, v := range byCase[k] {
+			line := fmt.Sprintf("[%s] %s", v.Severity, v.Invariant)
+			if v.Err != nil {
+				line += ": " + v.Err.Error()
+			}
+			if v.IsFatal() {
+				failures = append(failures, line)
+			} else {
+				notes = append(notes, line)
+			}
+		}
+
+		if len(failures) > 0 {
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d invariant violation(s)", len(failures)),
+				Type:    "InvariantViolation",
+				Text:    strings.Join(failures, "\n"),
+			}
+			suite.Failures++
+			doc.Failures++
+		}
+		tc.SystemOut = strings.Join(notes, "\n")
+
+		suite.Cases = append(suite.Cases, tc)
+		suite.Tests++
+		doc.Tests++
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("write junit report: %w", err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encode junit report: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("write junit report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+// NDJSONWriter writes a manifest as newline-delimited JSON records (see
+// manifest.StreamHeader) as it is produced, so nodes never have to be held
+// in memory. Call WriteHeader, WriteNode for each node, then WriteTrailer.
+type NDJSONWriter struct {
+	w     *bufio.Writer
+	enc   *json.Encoder
+	nodes int
+}
+
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	bw := bufio.NewWriter(w)
+	return &NDJSONWriter{w: bw, enc: json.NewEncoder(bw)}
+}
+
+func (w *NDJSONWriter) WriteHeader(m *manifest.Manifest) error {
+	return w.encode(manifest.NewStreamHeader(m))
+}
+
+func (w *NDJSONWriter) WriteNode(n *manifest.Node) error {
+	w.nodes++
+	return w.encode(manifest.StreamNode{Type: manifest.RecordNode, Node: n})
+}
+
+// WriteTrailer writes the skipped entries and the trailer, then flushes.
+func (w *NDJSONWriter) WriteTrailer(skipped []manifest.SkippedEntry, rollups []manifest.DirRollup) error {
+	for _, s := range skipped {
+		if err := w.encode(manifest.StreamSkipped{Type: manifest.RecordSkipped, SkippedEntry: s}); err != nil {
+			return err
+		}
+	}
+
+	err := w.encode(manifest.StreamTrailer{
+		Type:    manifest.RecordTrailer,
+		Nodes:   w.nodes,
+		Skipped: len(skipped),
+		Rollups: rollups,
+	})
+	if err != nil {
+		return err
+	}
+	return w.w.Flush()
+}
+
+func (w *NDJSONWriter) encode(v any) error {
+	if err := w.enc.Encode(v); err != nil {
+		return fmt.Errorf("encode ndjson record: %w", err)
+	}
+	return nil
+}
+
+// WriteNDJSON writes an in-memory manifest in the streaming layout. Node
+// rollups and subdirectory counts move to the trailer, as when streaming.
+func WriteNDJSON(path string, m *manifest.Manifest) error {
+	return writeFile(path, func(w io.Writer) error { return EncodeNDJSON(w, m) })
+}
+
+// EncodeNDJSON is WriteNDJSON for an open writer.
+func EncodeNDJSON(out io.Writer, m *manifest.Manifest) error {
+	w := NewNDJSONWriter(out)
+	if err := w.WriteHeader(m); err != nil {
+		return err
+	}
+
+	var rollups []manifest.DirRollup
+	for _, n := range m.Nodes {
+		if n.Rollup != nil {
+			rollups = append(rollups, manifest.DirRollup{Path: n.Path, DirectSubdirCount: n.DirectSubdirCount, Rollup: n.Rollup})
+		}
+
+		c := *n
+		c.Rollup = nil
+		c.DirectSubdirCount = 0
+		if err := w.WriteNode(&c); err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(rollups, func(i, j int) bool { return rollups[i].Path < rollups[j].Path })
+	return w.WriteTrailer(m.Skipped, rollups)
+}
@@ -0,0 +1,339 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+// RenderOptions controls the markdown and tree renderers.
+type RenderOptions struct {
+	// MaxDepth limits how many levels below a top-level node are expanded
+	// (0 = unlimited). Directories at the limit are summarized.
+	MaxDepth int
+
+	// Directories below either threshold are summarized on one line
+	// instead of expanded (0 = no threshold). Top-level nodes always expand.
+	CollapseFiles int
+	CollapseBytes int64
+}
+
+// WriteTree writes an ASCII tree of the manifest, one line per node, with
+// rollup annotations on directories.
+func WriteTree(path string, m *manifest.Manifest, opts RenderOptions) error {
+	return writeRendered(path, m, opts, RenderTree)
+}
+
+// WriteMarkdown writes the manifest as a nested markdown list under a short
+// heading, for pasting into chats.
+func WriteMarkdown(path string, m *manifest.Manifest, opts RenderOptions) error {
+	return writeRendered(path, m, opts, RenderMarkdown)
+}
+
+// RenderTree is WriteTree for an open writer.
+func RenderTree(w io.Writer, m *manifest.Manifest, opts RenderOptions) error {
+	return render(w, m, opts, renderTree)
+}
+
+// RenderMarkdown is WriteMarkdown for an open writer.
+func RenderMarkdown(w io.Writer, m *manifest.Manifest, opts RenderOptions) error {
+	return render(w, m, opts, renderMarkdown)
+}
+
+type renderFunc func(io.Writer, *manifest.TreeManifest, RenderOptions) error
+
+func writeRendered(path string, m *manifest.Manifest, opts RenderOptions,
+	fn func(io.Writer, *manifest.Manifest, RenderOptions) error) error {
+	return writeFile(path, func(w io.Writer) error { return fn(w, m, opts) })
+}
+
+func render(out io.Writer, m *manifest.Manifest, opts RenderOptions, fn renderFunc) error {
+	t, err := m.Tree()
+	if err != nil {
+		return fmt.Errorf("render manifest: %w", err)
+	}
+
+	w := bufio.NewWriter(out)
+	if err := fn(w, t, opts); err != nil {
+		return fmt.Errorf("render manifest: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+	return nil
+}
+
+func renderTree(w io.Writer, t *manifest.TreeManifest, opts RenderOptions) error {
+	r := newRenderer(t, opts)
+
+	var visit func(path, name string, n *manifest.TreeNode, prefix, branch string, depth int)
+	visit = func(path, name string, n *manifest.TreeNode, prefix, branch string, depth int) {
+		fmt.Fprintf(r.w(w), "%s%s%s  %s\n", prefix, branch, r.label(name, n), r.annotate(path, n, depth))
+		if !r.expand(path, n, depth) {
+			return
+		}
+
+		// Children sit under this node's branch, continuing its rail.
+		switch branch {
+		case "├── ":
+			prefix += "│   "
+		case "└── ":
+			prefix += "    "
+		}
+
+		keys := childKeys(n)
+		for i, k := range keys {
+			b := "├── "
+			if i == len(keys)-1 {
+				b = "└── "
+			}
+			visit(manifest.ChildPath(path, n.Archive != "", k), k, n.Children[k], prefix, b, depth+1)
+		}
+	}
+
+	for _, k := range childKeys(&manifest.TreeNode{Children: t.Tree}) {
+		visit(k, k, t.Tree[k], "", "", 0)
+	}
+	return r.err
+}
+
+func renderMarkdown(w io.Writer, t *manifest.TreeManifest, opts RenderOptions) error {
+	r := newRenderer(t, opts)
+
+	fmt.Fprintf(r.w(w), "# Manifest: `%s`\n\n", t.Root)
+	if !t.Generated.IsZero() {
+		fmt.Fprintf(r.w(w), "Generated %s\n\n", t.Generated.UTC().Format(time.RFC3339))
+	}
+
+	var visit func(path, name string, n *manifest.TreeNode, indent string, depth int)
+	visit = func(path, name string, n *manifest.TreeNode, indent string, depth int) {
+		line := fmt.Sprintf("%s- `%s`", indent, r.label(name, n))
+		if a := r.annotate(path, n, depth); a != "" {
+			line += " — " + a
+		}
+		fmt.Fprintln(r.w(w), line)
+
+		if !r.expand(path, n, depth) {
+			return
+		}
+		for _, k := range childKeys(n) {
+			visit(manifest.ChildPath(path, n.Archive != "", k), k, n.Children[k], indent+"  ", depth+1)
+		}
+	}
+
+	for _, k := range childKeys(&manifest.TreeNode{Children: t.Tree}) {
+		visit(k, k, t.Tree[k], "", 0)
+	}
+	return r.err
+}
+
+// renderer holds what both layouts share: subtree totals, the expand
+// decision and annotation text.
+type renderer struct {
+	opts      RenderOptions
+	totals    map[*manifest.TreeNode]subtreeTotals
+	collapsed map[string]manifest.CollapsedDir // by a token budget
+	err       error
+}
+
+type subtreeTotals struct {
+	files   int
+	bytes   int64
+	exts    map[string]int
+	lastMod int64
+}
+
+func newRenderer(t *manifest.TreeManifest, opts RenderOptions) *renderer {
+	r := &renderer{
+		opts:      opts,
+		totals:    make(map[*manifest.TreeNode]subtreeTotals),
+		collapsed: make(map[string]manifest.CollapsedDir),
+	}
+	if t.Budget != nil {
+		for _, c := range t.Budget.Collapsed {
+			r.collapsed[c.Path] = c
+		}
+	}
+	return r
+}
+
+// w records the first write error so render loops can stay unchecked.
+func (r *renderer) w(w io.Writer) io.Writer {
+	return errWriter{w: w, err: &r.err}
+}
+
+type errWriter struct {
+	w   io.Writer
+	err *error
+}
+
+func (e errWriter) Write(p []byte) (int, error) {
+	if *e.err != nil {
+		return 0, *e.err
+	}
+	n, err := e.w.Write(p)
+	if err != nil {
+		*e.err = err
+	}
+	return n, err
+}
+
+func (r *renderer) label(name string, n *manifest.TreeNode) string {
+	if n.IsDir && name != "." {
+		name += "/"
+	}
+	return name
+}
+
+// expand reports whether a directory's children are listed.
+func (r *renderer) expand(path string, n *manifest.TreeNode, depth int) bool {
+	if !n.IsDir || len(n.Children) == 0 {
+		return false
+	}
+	if r.opts.MaxDepth > 0 && depth >= r.opts.MaxDepth {
+		return false
+	}
+	return depth == 0 || !r.small(path, n)
+}
+
+func (r *renderer) small(path string, n *manifest.TreeNode) bool {
+	t := r.subtree(path, n)
+	return (r.opts.CollapseFiles > 0 && t.files < r.opts.CollapseFiles) ||
+		(r.opts.CollapseBytes > 0 && t.bytes < r.opts.CollapseBytes)
+}
+
+// annotate describes a node: a file's size, or a directory's subtree
+// totals, dominant extensions and last modification. Directories whose
+// contents are not listed end in "…".
+func (r *renderer) annotate(path string, n *manifest.TreeNode, depth int) string {
+	if !n.IsDir {
+		return formatBytes(n.SizeBytes)
+	}
+
+	t := r.subtree(path, n)
+	_, budgeted := r.collapsed[path]
+
+	parts := []string{plural(t.files, "file"), formatBytes(t.bytes)}
+	if exts := dominantExtensions(t.exts, 3); exts != "" {
+		parts = append(parts, exts)
+	}
+	if t.lastMod > 0 {
+		parts = append(parts, "modified "+time.Unix(t.lastMod, 0).UTC().Format("2006-01-02"))
+	}
+
+	s := strings.Join(parts, " · ")
+	if budgeted || (len(n.Children) > 0 && !r.expand(path, n, depth)) {
+		s += " …"
+	}
+	return s
+}
+
+// subtree totals a directory from its rollup, or from its files when it
+// has none, plus the totals of its subdirectories. A directory collapsed
+// by a token budget has its dropped subtree's totals instead.
+func (r *renderer) subtree(path string, n *manifest.TreeNode) subtreeTotals {
+	if t, ok := r.totals[n]; ok {
+		return t
+	}
+
+	t := subtreeTotals{exts: make(map[string]int)}
+	if c, ok := r.collapsed[path]; ok {
+		t.files, t.bytes, t.lastMod = c.Files, c.SizeBytes, c.LastModified
+		for ext, n := range c.Extensions {
+			t.exts[ext] += n
+		}
+		r.totals[n] = t
+		return t
+	}
+	if n.Rollup != nil {
+		t.files = n.Rollup.TotalFiles
+		t.bytes = n.Rollup.Size.Total
+		t.lastMod = n.Rollup.LastModified
+		for ext, c := range n.Rollup.Extensions {
+			t.exts[ext] += c
+		}
+	}
+
+	for k, c := range n.Children {
+		if c.IsDir {
+			sub := r.subtree(manifest.ChildPath(path, n.Archive != "", k), c)
+			t.files += sub.files
+			t.bytes += sub.bytes
+			t.lastMod = max(t.lastMod, sub.lastMod)
+			for ext, n := range sub.exts {
+				t.exts[ext] += n
+			}
+			continue
+		}
+		if n.Rollup == nil {
+			t.files++
+			t.bytes += c.SizeBytes
+			t.lastMod = max(t.lastMod, c.MtimeUnix)
+			if ext := filepath.Ext(k); ext != "" {
+				t.exts[ext]++
+			}
+		}
+	}
+
+	r.totals[n] = t
+	return t
+}
+
+func childKeys(n *manifest.TreeNode) []string {
+	keys := make([]string, 0, len(n.Children))
+	for k := range n.Children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// dominantExtensions lists the top n extensions by count, ties by name.
+func dominantExtensions(exts map[string]int, n int) string {
+	names := make([]string, 0, len(exts))
+	for ext := range exts {
+		names = append(names, ext)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if exts[names[i]] != exts[names[j]] {
+			return exts[names[i]] > exts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > n {
+		names = names[:n]
+	}
+
+	parts := make([]string, len(names))
+	for i, ext := range names {
+		parts[i] = fmt.Sprintf("%s %d", ext, exts[ext])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// formatBytes uses binary units, like policy size literals.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,77 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+func renderFixture(t *testing.T) *manifest.TreeManifest {
+	t.Helper()
+
+	m := &manifest.Manifest{
+		Root: "repo",
+		Nodes: []*manifest.Node{
+			{Path: ".", IsDir: true},
+			{Path: "README.md", SizeBytes: 100, MtimeUnix: 1767225600},
+			{Path: "src", IsDir: true},
+			{Path: "src/a.go", SizeBytes: 2048},
+			{Path: "src/b.go", SizeBytes: 1024},
+			{Path: "src/gen", IsDir: true},
+			{Path: "src/gen/x.pb.go", SizeBytes: 10},
+		},
+	}
+	if err := m.BuildRollups(manifest.RollupOptions{EnableSizeBytes: true, EnableFileTypes: true}); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := m.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestRenderTree(t *testing.T) {
+	var buf bytes.Buffer
+	if err := renderTree(&buf, renderFixture(t), RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `.  4 files · 3.1 KB · .go 3, .md 1 · modified 2026-01-01
+├── README.md  100 B
+└── src/  3 files · 3.0 KB · .go 3
+    ├── a.go  2.0 KB
+    ├── b.go  1.0 KB
+    └── gen/  1 file · 10 B · .go 1
+        └── x.pb.go  10 B
+`
+	if got := buf.String(); got != want {
+		t.Errorf("tree:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderMarkdownCollapses(t *testing.T) {
+	var buf bytes.Buffer
+	err := renderMarkdown(&buf, renderFixture(t), RenderOptions{CollapseBytes: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	if !strings.HasPrefix(got, "# Manifest: `repo`\n") {
+		t.Errorf("missing heading:\n%s", got)
+	}
+	if !strings.Contains(got, "    - `gen/` — 1 file · 10 B · .go 1 …\n") || strings.Contains(got, "x.pb.go") {
+		t.Errorf("expected gen/ to be collapsed:\n%s", got)
+	}
+
+	buf.Reset()
+	if err := renderMarkdown(&buf, renderFixture(t), RenderOptions{MaxDepth: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); !strings.Contains(got, "  - `src/` — 3 files · 3.0 KB · .go 3 …\n") || strings.Contains(got, "a.go") {
+		t.Errorf("expected src/ to stop at the depth limit:\n%s", got)
+	}
+}
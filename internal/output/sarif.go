@@ -0,0 +1,150 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/dtnitsch/manifestor/internal/build"
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version"`
+	Rules   []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	RuleIndex  int               `json:"ruleIndex"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// WriteViolationsSARIF writes a validation report as a SARIF 2.1.0 log.
+// Each capability becomes a rule and each violating node a result location.
+// Violations without a node path are located at source, the manifest file.
+func WriteViolationsSARIF(w io.Writer, source string, r *manifest.ValidationReport) error {
+	ruleIndex := make(map[string]int)
+	var capabilities []string
+	for _, v := range r.Violations {
+		if _, ok := ruleIndex[v.Capability]; !ok {
+			ruleIndex[v.Capability] = 0
+			capabilities = append(capabilities, v.Capability)
+		}
+	}
+	sort.Strings(capabilities)
+
+	rules := make([]sarifRule, len(capabilities))
+	for i, c := range capabilities {
+		ruleIndex[c] = i
+		rules[i] = sarifRule{
+			ID:               c,
+			ShortDescription: sarifMessage{Text: fmt.Sprintf("manifest %s invariants", c)},
+		}
+	}
+
+	results := make([]sarifResult, 0, len(r.Violations))
+	for _, v := range r.Violations {
+		loc := sarifArtifactLocation{URI: source}
+		if v.Path != "" {
+			loc = sarifArtifactLocation{URI: v.Path, URIBaseID: "%SRCROOT%"}
+		}
+
+		msg := v.Invariant
+		if v.Err != nil {
+			msg += ": " + v.Err.Error()
+		} else if v.Description != "" {
+			msg += ": " + v.Description
+		}
+
+		results = append(results, sarifResult{
+			RuleID:    v.Capability,
+			RuleIndex: ruleIndex[v.Capability],
+			Level:     sarifLevel(v.Severity),
+			Message:   sarifMessage{Text: msg},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: loc},
+			}},
+			Properties: map[string]string{"invariant": v.Invariant},
+		})
+	}
+
+	log := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:    build.Name,
+				Version: build.Version,
+				Rules:   rules,
+			}},
+			Results: results,
+		}},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(log); err != nil {
+		return fmt.Errorf("encode sarif report: %w", err)
+	}
+	return nil
+}
+
+func sarifLevel(s manifest.Severity) string {
+	switch s {
+	case manifest.SeverityError:
+		return "error"
+	case manifest.SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
@@ -0,0 +1,311 @@
+package output
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+	_ "modernc.org/sqlite" // pure-Go driver, registered as "sqlite"
+)
+
+// SQLiteOptions controls WriteSQLite.
+type SQLiteOptions struct {
+	// History keeps an existing database and appends this scan's nodes to
+	// its history table, keyed by generated_at. Without it the file is
+	// replaced.
+	History bool
+}
+
+// sqliteSchema holds the current scan. Tables are recreated on every write;
+// history is the only table that accumulates.
+const sqliteSchema = `
+DROP TABLE IF EXISTS meta;
+DROP TABLE IF EXISTS nodes;
+DROP TABLE IF EXISTS rollups;
+DROP TABLE IF EXISTS extensions;
+DROP TABLE IF EXISTS skipped;
+
+CREATE TABLE meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+
+CREATE TABLE nodes (
+	path                TEXT PRIMARY KEY,
+	parent              TEXT,
+	name                TEXT NOT NULL,
+	depth               INTEGER NOT NULL,
+	is_dir              INTEGER NOT NULL,
+	ext                 TEXT,
+	size_bytes          INTEGER NOT NULL,
+	mtime_unix          INTEGER NOT NULL,
+	inode               INTEGER,
+	hash                TEXT,
+	archive             TEXT,
+	file_count          INTEGER,
+	direct_subdir_count INTEGER
+);
+CREATE INDEX nodes_parent ON nodes (parent);
+CREATE INDEX nodes_ext ON nodes (ext);
+
+CREATE TABLE rollups (
+	path                  TEXT PRIMARY KEY REFERENCES nodes (path),
+	total_files           INTEGER NOT NULL,
+	total_descendant_dirs INTEGER NOT NULL,
+	size_total            INTEGER NOT NULL,
+	size_min              INTEGER,
+	size_max              INTEGER,
+	size_mean             INTEGER,
+	size_median           INTEGER,
+	size_p50              INTEGER,
+	size_p90              INTEGER,
+	size_p99              INTEGER,
+	last_modified         INTEGER
+);
+
+CREATE TABLE extensions (
+	path  TEXT NOT NULL REFERENCES nodes (path),
+	ext   TEXT NOT NULL,
+	count INTEGER NOT NULL,
+	PRIMARY KEY (path, ext)
+);
+CREATE INDEX extensions_ext ON extensions (ext);
+
+CREATE TABLE skipped (
+	path   TEXT PRIMARY KEY,
+	is_dir INTEGER NOT NULL,
+	reason TEXT NOT NULL,
+	rule   TEXT
+);
+
+CREATE TABLE IF NOT EXISTS history (
+	scanned_at TEXT NOT NULL,
+	path       TEXT NOT NULL,
+	is_dir     INTEGER NOT NULL,
+	size_bytes INTEGER NOT NULL,
+	mtime_unix INTEGER NOT NULL,
+	hash       TEXT,
+	PRIMARY KEY (scanned_at, path)
+);
+CREATE INDEX IF NOT EXISTS history_path ON history (path);
+`
+
+// WriteSQLite writes the manifest as a SQLite database with nodes, rollups,
+// extensions, skipped and meta tables, for ad-hoc SQL.
+func WriteSQLite(path string, m *manifest.Manifest, opts SQLiteOptions) error {
+	if !opts.History {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("replace %s: %w", path, err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(sqliteSchema); err != nil {
+		return fmt.Errorf("create tables: %w", err)
+	}
+
+	w := sqliteWriter{tx: tx}
+	w.meta(m)
+	w.nodes(m)
+	w.skipped(m.Skipped)
+	if opts.History {
+		w.history(m)
+	}
+	if w.err != nil {
+		return w.err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// sqliteWriter keeps the first error so the table writers read straight
+// through, like the renderers' errWriter.
+type sqliteWriter struct {
+	tx  *sql.Tx
+	err error
+}
+
+func (w *sqliteWriter) prepare(query string) *sql.Stmt {
+	if w.err != nil {
+		return nil
+	}
+	stmt, err := w.tx.Prepare(query)
+	if err != nil {
+		w.err = fmt.Errorf("prepare %q: %w", query, err)
+	}
+	return stmt
+}
+
+func (w *sqliteWriter) exec(stmt *sql.Stmt, table string, args ...any) {
+	if w.err != nil {
+		return
+	}
+	if _, err := stmt.Exec(args...); err != nil {
+		w.err = fmt.Errorf("insert into %s: %w", table, err)
+	}
+}
+
+func (w *sqliteWriter) meta(m *manifest.Manifest) {
+	stmt := w.prepare(`INSERT INTO meta (key, value) VALUES (?, ?)`)
+	if stmt == nil {
+		return
+	}
+	defer stmt.Close()
+
+	put := func(key, value string) { w.exec(stmt, "meta", key, value) }
+	putJSON := func(key string, v any) {
+		data, err := json.Marshal(v)
+		if err != nil && w.err == nil {
+			w.err = fmt.Errorf("encode meta %s: %w", key, err)
+		}
+		put(key, string(data))
+	}
+
+	put("version", m.Manifest.Version)
+	put("root", m.Root)
+	put("generated_at", m.Generated.UTC().Format(time.RFC3339Nano))
+	putJSON("generator", m.Manifest.Generator)
+	putJSON("schema", m.Manifest.Schema)
+	putJSON("capabilities", m.Manifest.Capabilities)
+	if len(m.Roots) > 0 {
+		putJSON("roots", m.Roots)
+	}
+	if m.Filters != nil {
+		putJSON("filters", m.Filters)
+	}
+	if m.Archives != nil {
+		putJSON("archives", m.Archives)
+	}
+	if m.Budget != nil {
+		putJSON("budget", m.Budget)
+	}
+}
+
+func (w *sqliteWriter) nodes(m *manifest.Manifest) {
+	nodes := w.prepare(`INSERT INTO nodes (path, parent, name, depth, is_dir, ext, size_bytes, mtime_unix,
+		inode, hash, archive, file_count, direct_subdir_count) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	rollups := w.prepare(`INSERT INTO rollups (path, total_files, total_descendant_dirs, size_total, size_min,
+		size_max, size_mean, size_median, size_p50, size_p90, size_p99, last_modified)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	exts := w.prepare(`INSERT INTO extensions (path, ext, count) VALUES (?, ?, ?)`)
+	if w.err != nil {
+		return
+	}
+	defer nodes.Close()
+	defer rollups.Close()
+	defer exts.Close()
+
+	idx := newNodeIndex(m)
+	for _, n := range m.Nodes {
+		var parent any
+		if p, ok := idx.parent[n.Path]; ok {
+			parent = p
+		}
+
+		var ext any
+		if !n.IsDir {
+			if e := filepath.Ext(n.Path); e != "" {
+				ext = e
+			}
+		}
+
+		w.exec(nodes, "nodes", n.Path, parent, filepath.Base(n.Path), idx.depth[n.Path], n.IsDir, ext,
+			n.SizeBytes, n.MtimeUnix, int64(n.Inode), nullString(n.Hash), nullString(n.Archive),
+			n.FileCount, n.DirectSubdirCount)
+
+		r := n.Rollup
+		if r == nil {
+			continue
+		}
+		var p50, p90, p99 any
+		if p := r.Size.Percentiles; p != nil {
+			p50, p90, p99 = p.P50, p.P90, p.P99
+		}
+		w.exec(rollups, "rollups", n.Path, r.TotalFiles, r.TotalDescendantDirs, r.Size.Total, r.Size.Min,
+			r.Size.Max, r.Size.Mean, r.Size.Median, p50, p90, p99, r.LastModified)
+		for ext, count := range r.Extensions {
+			w.exec(exts, "extensions", n.Path, ext, count)
+		}
+	}
+}
+
+func (w *sqliteWriter) skipped(skipped []manifest.SkippedEntry) {
+	stmt := w.prepare(`INSERT OR REPLACE INTO skipped (path, is_dir, reason, rule) VALUES (?, ?, ?, ?)`)
+	if stmt == nil {
+		return
+	}
+	defer stmt.Close()
+
+	for _, s := range skipped {
+		w.exec(stmt, "skipped", s.Path, s.IsDir, s.Reason, nullString(s.Rule))
+	}
+}
+
+// history appends the scan's nodes; rewriting the same scan replaces its
+// rows.
+func (w *sqliteWriter) history(m *manifest.Manifest) {
+	stmt := w.prepare(`INSERT OR REPLACE INTO history (scanned_at, path, is_dir, size_bytes, mtime_unix, hash)
+		VALUES (?, ?, ?, ?, ?, ?)`)
+	if stmt == nil {
+		return
+	}
+	defer stmt.Close()
+
+	at := m.Generated.UTC().Format(time.RFC3339Nano)
+	for _, n := range m.Nodes {
+		w.exec(stmt, "history", at, n.Path, n.IsDir, n.SizeBytes, n.MtimeUnix, nullString(n.Hash))
+	}
+}
+
+// nodeIndex holds each node's parent, when it is in the manifest, and its
+// depth below its top-level node, for the table formats.
+type nodeIndex struct {
+	parent map[string]string
+	depth  map[string]int
+}
+
+func newNodeIndex(m *manifest.Manifest) nodeIndex {
+	idx := nodeIndex{parent: make(map[string]string), depth: make(map[string]int, len(m.Nodes))}
+
+	parentOf := m.ParentFunc()
+	present := make(map[string]bool, len(m.Nodes))
+	for _, n := range m.Nodes {
+		present[n.Path] = true
+	}
+
+	// Nodes come parents first, so the parent's depth is known.
+	for _, n := range m.Nodes {
+		if p := parentOf(n.Path); n.Path != "." && present[p] {
+			idx.parent[n.Path] = p
+			idx.depth[n.Path] = idx.depth[p] + 1
+		}
+	}
+	return idx
+}
+
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,70 @@
+package output
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+func TestWriteSQLite(t *testing.T) {
+	m := &manifest.Manifest{
+		Root:      "repo",
+		Generated: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Nodes: []*manifest.Node{
+			{Path: ".", IsDir: true},
+			{Path: "README.md", SizeBytes: 100},
+			{Path: "src", IsDir: true},
+			{Path: "src/a.go", SizeBytes: 2048},
+			{Path: "src/b.go", SizeBytes: 1024},
+			{Path: "src/gen", IsDir: true},
+			{Path: "src/gen/x.pb.go", SizeBytes: 10},
+		},
+		Skipped: []manifest.SkippedEntry{{Path: "node_modules", IsDir: true, Reason: "blocked", Rule: "node_modules"}},
+	}
+	if err := m.BuildRollups(manifest.RollupOptions{EnableSizeBytes: true, EnableFileTypes: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "manifest.db")
+	if err := WriteSQLite(path, m, SQLiteOptions{History: true}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	m.Generated = m.Generated.Add(time.Hour)
+	if err := WriteSQLite(path, m, SQLiteOptions{History: true}); err != nil {
+		t.Fatalf("second write: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, q := range []struct {
+		query string
+		want  string
+	}{
+		{`SELECT value FROM meta WHERE key = 'root'`, "repo"},
+		{`SELECT count(*) FROM nodes`, "7"},
+		{`SELECT group_concat(name, ',') FROM (SELECT name FROM nodes WHERE parent = 'src' ORDER BY name)`, "a.go,b.go,gen"},
+		{`SELECT depth FROM nodes WHERE path = 'src/gen/x.pb.go'`, "3"},
+		{`SELECT sum(size_bytes) FROM nodes WHERE ext = '.go'`, "3082"},
+		{`SELECT size_total FROM rollups WHERE path = 'src'`, "3072"},
+		{`SELECT count FROM extensions WHERE path = 'src' AND ext = '.go'`, "2"},
+		{`SELECT reason FROM skipped WHERE path = 'node_modules'`, "blocked"},
+		{`SELECT count(DISTINCT scanned_at) || '/' || count(*) FROM history`, "2/14"},
+		{`SELECT count(*) FROM sqlite_master WHERE type = 'index' AND name IN ('nodes_parent', 'nodes_ext', 'extensions_ext')`, "3"},
+	} {
+		var got string
+		if err := db.QueryRow(q.query).Scan(&got); err != nil {
+			t.Errorf("%s: %v", q.query, err)
+			continue
+		}
+		if got != q.want {
+			t.Errorf("%s = %s, want %s", q.query, got, q.want)
+		}
+	}
+}
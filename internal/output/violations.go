@@ -0,0 +1,121 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+// violationRecord is the machine-readable form of an InvariantViolation.
+type violationRecord struct {
+	Path        string            `json:"path,omitempty"`
+	Capability  string            `json:"capability"`
+	Invariant   string            `json:"invariant"`
+	Description string            `json:"description,omitempty"`
+	Severity    manifest.Severity `json:"severity"`
+	Error       string            `json:"error,omitempty"`
+}
+
+type summaryRecord struct {
+	Total        int            `json:"total"`
+	Errors       int            `json:"errors"`
+	Warnings     int            `json:"warnings"`
+	Infos        int            `json:"infos"`
+	Baselined    int            `json:"baselined,omitempty"`
+	Fixed        int            `json:"fixed,omitempty"`
+	ByCapability map[string]int `json:"by_capability,omitempty"`
+	ByInvariant  map[string]int `json:"by_invariant,omitempty"`
+}
+
+type reportRecord struct {
+	Manifest   string            `json:"manifest,omitempty"`
+	Valid      bool              `json:"valid"`
+	Truncated  bool              `json:"truncated,omitempty"`
+	Summary    summaryRecord     `json:"summary"`
+	Violations []violationRecord `json:"violations"`
+}
+
+// WriteViolationsJSON writes a validation report as a single JSON document.
+// source names the validated manifest and may be empty.
+func WriteViolationsJSON(w io.Writer, source string, r *manifest.ValidationReport) error {
+	rec := reportRecord{
+		Manifest:  source,
+		Valid:     !r.Summary.HasErrors(),
+		Truncated: r.Truncated,
+		Summary: summaryRecord{
+			Total:        r.Summary.Total,
+			Errors:       r.Summary.Errors,
+			Warnings:     r.Summary.Warnings,
+			Infos:        r.Summary.Infos,
+			Baselined:    r.Summary.Baselined,
+			Fixed:        r.Summary.Fixed,
+			ByCapability: r.Summary.ByCapability,
+			ByInvariant:  r.Summary.ByInvariant,
+		},
+		Violations: make([]violationRecord, 0, len(r.Violations)),
+	}
+
+	for _, v := range r.Violations {
+		vr := violationRecord{
+			Path:        v.Path,
+			Capability:  v.Capability,
+			Invariant:   v.Invariant,
+			Description: v.Description,
+			Severity:    v.Severity,
+		}
+		if v.Err != nil {
+			vr.Error = v.Err.Error()
+		}
+		rec.Violations = append(rec.Violations, vr)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(rec); err != nil {
+		return fmt.Errorf("encode validation report: %w", err)
+	}
+	return nil
+}
+
+// WriteViolationsText writes a validation report as one line per violation
+// followed by a summary line.
+func WriteViolationsText(w io.Writer, source string, r *manifest.ValidationReport) error {
+	for _, v := range r.Violations {
+		line := fmt.Sprintf("%-7s %s", v.Severity, v.Error())
+		if v.Err != nil {
+			line += ": " + v.Err.Error()
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return fmt.Errorf("write validation report: %w", err)
+		}
+	}
+
+	if r.Truncated {
+		if _, err := fmt.Fprintln(w, "... violation limit reached; remaining violations not reported"); err != nil {
+			return fmt.Errorf("write validation report: %w", err)
+		}
+	}
+
+	status := "valid"
+	if r.Summary.HasErrors() {
+		status = "invalid"
+	}
+	if source == "" {
+		source = "manifest"
+	}
+
+	line := fmt.Sprintf("%s: %s (%d errors, %d warnings, %d info",
+		source, status, r.Summary.Errors, r.Summary.Warnings, r.Summary.Infos)
+	if r.Summary.Baselined > 0 || r.Summary.Fixed > 0 {
+		line += fmt.Sprintf("; %d baselined, %d fixed", r.Summary.Baselined, r.Summary.Fixed)
+	}
+
+	_, err := fmt.Fprintln(w, line+")")
+	if err != nil {
+		return fmt.Errorf("write validation report: %w", err)
+	}
+	return nil
+}
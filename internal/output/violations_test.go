@@ -0,0 +1,68 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+func testReport() *manifest.ValidationReport {
+	violations := []manifest.InvariantViolation{
+		{Path: "a", Capability: "size_stats", Invariant: "size.min.present", Severity: manifest.SeverityError, Err: errors.New("size.min missing")},
+		{Path: "a", Capability: "size_stats", Invariant: "size.max.present", Severity: manifest.SeverityError, Err: errors.New("size.max missing")},
+		{Path: "b/c.txt", Capability: "policy", Invariant: "no-txt", Severity: manifest.SeverityWarning},
+		{Capability: "size_buckets", Invariant: "capability.has_invariants", Severity: manifest.SeverityInfo},
+	}
+	return &manifest.ValidationReport{
+		Violations: violations,
+		Summary:    manifest.SummarizeViolations(violations),
+	}
+}
+
+func TestWriteViolationsSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteViolationsSARIF(&buf, "manifest.yaml", testReport()); err != nil {
+		t.Fatal(err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid sarif json: %v", err)
+	}
+
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 3 || len(run.Results) != 4 {
+		t.Fatalf("expected 3 rules and 4 results, got %d and %d", len(run.Tool.Driver.Rules), len(run.Results))
+	}
+
+	for _, res := range run.Results {
+		if run.Tool.Driver.Rules[res.RuleIndex].ID != res.RuleID {
+			t.Fatalf("rule index %d does not point at %s", res.RuleIndex, res.RuleID)
+		}
+	}
+
+	last := run.Results[3]
+	if last.Level != "note" || last.Locations[0].PhysicalLocation.ArtifactLocation.URI != "manifest.yaml" {
+		t.Fatalf("unexpected result for path-less violation: %+v", last)
+	}
+}
+
+func TestWriteViolationsJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteViolationsJUnit(&buf, "manifest.yaml", testReport()); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid junit xml: %v", err)
+	}
+
+	if len(doc.Suites) != 3 || doc.Tests != 3 || doc.Failures != 1 {
+		t.Fatalf("unexpected totals: suites=%d tests=%d failures=%d", len(doc.Suites), doc.Tests, doc.Failures)
+	}
+}
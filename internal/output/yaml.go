@@ -2,23 +2,26 @@ package output
 
 import (
 	"fmt"
-	"os"
+	"io"
 
-	"github.com/dtnitsch/manifestor/internal/manifest"
 	"gopkg.in/yaml.v3"
 )
 
-func WriteYAML(path string, m *manifest.Manifest) error {
-	f, err := os.Create(path)
-	if err != nil {
-		return fmt.Errorf("create output file: %w", err)
-	}
-	defer f.Close()
+// WriteYAML writes a manifest document, either a *manifest.Manifest or its
+// *manifest.TreeManifest layout, to path as Create opens it.
+func WriteYAML(path string, doc any) error {
+	return writeFile(path, func(w io.Writer) error { return EncodeYAML(w, doc) })
+}
 
-	enc := yaml.NewEncoder(f)
+// EncodeYAML is WriteYAML for an open writer.
+func EncodeYAML(w io.Writer, doc any) error {
+	enc := yaml.NewEncoder(w)
 	enc.SetIndent(2)
 
-	if err := enc.Encode(m); err != nil {
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+	if err := enc.Close(); err != nil {
 		return fmt.Errorf("encode manifest: %w", err)
 	}
 	return nil
@@ -0,0 +1,473 @@
+package policy
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+type kind int
+
+const (
+	kindBool kind = iota
+	kindInt
+	kindString
+)
+
+func (k kind) String() string {
+	switch k {
+	case kindBool:
+		return "bool"
+	case kindInt:
+		return "int"
+	default:
+		return "string"
+	}
+}
+
+type value struct {
+	b bool
+	i int64
+	s string
+}
+
+// env is the evaluation context for a single node.
+type env struct {
+	node     *manifest.Node
+	children map[string][]*manifest.Node
+}
+
+// compiled is a type-checked expression ready for evaluation.
+type compiled struct {
+	kind kind
+	eval func(*env) value
+}
+
+// Expr is a compiled boolean predicate over a manifest node.
+type Expr struct {
+	src string
+	c   compiled
+}
+
+func (e *Expr) String() string { return e.src }
+
+func (e *Expr) match(en *env) bool { return e.c.eval(en).b }
+
+// ParseExpr compiles a predicate. It must evaluate to a bool.
+func ParseExpr(src string) (*Expr, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{toks: toks}
+	c, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+	}
+	if c.kind != kindBool {
+		return nil, fmt.Errorf("expression must be bool, got %s", c.kind)
+	}
+
+	return &Expr{src: src, c: c}, nil
+}
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isOp(op string) bool {
+	t := p.peek()
+	return t.kind == tokOp && t.text == op
+}
+
+func (p *parser) parseOr() (compiled, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return left, err
+	}
+	for p.isOp("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return right, err
+		}
+		if err := expectKinds("||", kindBool, left, right); err != nil {
+			return left, err
+		}
+		l, r := left.eval, right.eval
+		left = compiled{kind: kindBool, eval: func(e *env) value {
+			return value{b: l(e).b || r(e).b}
+		}}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (compiled, error) {
+	left, err := p.parseCmp()
+	if err != nil {
+		return left, err
+	}
+	for p.isOp("&&") {
+		p.next()
+		right, err := p.parseCmp()
+		if err != nil {
+			return right, err
+		}
+		if err := expectKinds("&&", kindBool, left, right); err != nil {
+			return left, err
+		}
+		l, r := left.eval, right.eval
+		left = compiled{kind: kindBool, eval: func(e *env) value {
+			return value{b: l(e).b && r(e).b}
+		}}
+	}
+	return left, nil
+}
+
+func (p *parser) parseCmp() (compiled, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return left, err
+	}
+
+	t := p.peek()
+	if t.kind != tokOp {
+		return left, nil
+	}
+	switch t.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return left, nil
+	}
+	p.next()
+
+	right, err := p.parseUnary()
+	if err != nil {
+		return right, err
+	}
+	if left.kind != right.kind {
+		return left, fmt.Errorf("cannot compare %s %s %s at offset %d", left.kind, t.text, right.kind, t.pos)
+	}
+	if left.kind == kindBool && t.text != "==" && t.text != "!=" {
+		return left, fmt.Errorf("operator %s not defined on bool at offset %d", t.text, t.pos)
+	}
+
+	k, op, l, r := left.kind, t.text, left.eval, right.eval
+	return compiled{kind: kindBool, eval: func(e *env) value {
+		return value{b: compare(k, op, l(e), r(e))}
+	}}, nil
+}
+
+func compare(k kind, op string, a, b value) bool {
+	var c int
+	switch k {
+	case kindBool:
+		if a.b != b.b {
+			c = 1
+		}
+	case kindInt:
+		switch {
+		case a.i < b.i:
+			c = -1
+		case a.i > b.i:
+			c = 1
+		}
+	default:
+		c = strings.Compare(a.s, b.s)
+	}
+
+	switch op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+func (p *parser) parseUnary() (compiled, error) {
+	if p.isOp("!") {
+		t := p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return inner, err
+		}
+		if inner.kind != kindBool {
+			return inner, fmt.Errorf("operator ! requires bool at offset %d", t.pos)
+		}
+		f := inner.eval
+		return compiled{kind: kindBool, eval: func(e *env) value {
+			return value{b: !f(e).b}
+		}}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (compiled, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokInt:
+		v := value{i: t.num}
+		return compiled{kind: kindInt, eval: func(*env) value { return v }}, nil
+
+	case tokString:
+		v := value{s: t.text}
+		return compiled{kind: kindString, eval: func(*env) value { return v }}, nil
+
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return inner, err
+		}
+		if p.next().kind != tokRParen {
+			return inner, fmt.Errorf("missing ) for ( at offset %d", t.pos)
+		}
+		return inner, nil
+
+	case tokIdent:
+		switch t.text {
+		case "true", "false":
+			v := value{b: t.text == "true"}
+			return compiled{kind: kindBool, eval: func(*env) value { return v }}, nil
+		}
+
+		if p.peek().kind == tokLParen {
+			return p.parseCall(t)
+		}
+
+		f, ok := fields[t.text]
+		if !ok {
+			return compiled{}, fmt.Errorf("unknown field %q at offset %d", t.text, t.pos)
+		}
+		return f, nil
+	}
+
+	if t.kind == tokEOF {
+		return compiled{}, fmt.Errorf("unexpected end of expression")
+	}
+	return compiled{}, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+}
+
+func (p *parser) parseCall(name token) (compiled, error) {
+	fn, ok := funcs[name.text]
+	if !ok {
+		return compiled{}, fmt.Errorf("unknown function %q at offset %d", name.text, name.pos)
+	}
+	p.next() // (
+
+	var args []compiled
+	if p.peek().kind != tokRParen {
+		for {
+			a, err := p.parseOr()
+			if err != nil {
+				return a, err
+			}
+			args = append(args, a)
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if p.next().kind != tokRParen {
+		return compiled{}, fmt.Errorf("missing ) in call to %s at offset %d", name.text, name.pos)
+	}
+
+	if len(args) != len(fn.args) {
+		return compiled{}, fmt.Errorf("%s expects %d arguments, got %d", name.text, len(fn.args), len(args))
+	}
+	for i, a := range args {
+		if a.kind != fn.args[i] {
+			return compiled{}, fmt.Errorf("%s argument %d must be %s, got %s", name.text, i+1, fn.args[i], a.kind)
+		}
+	}
+
+	return compiled{kind: fn.ret, eval: func(e *env) value {
+		vals := make([]value, len(args))
+		for i, a := range args {
+			vals[i] = a.eval(e)
+		}
+		return fn.call(e, vals)
+	}}, nil
+}
+
+func expectKinds(op string, k kind, operands ...compiled) error {
+	for _, o := range operands {
+		if o.kind != k {
+			return fmt.Errorf("operator %s requires %s operands, got %s", op, k, o.kind)
+		}
+	}
+	return nil
+}
+
+func intField(f func(*manifest.Node) int64) compiled {
+	return compiled{kind: kindInt, eval: func(e *env) value { return value{i: f(e.node)} }}
+}
+
+func rollupField(f func(*manifest.Rollup) int64) compiled {
+	return intField(func(n *manifest.Node) int64 {
+		if n.Rollup == nil {
+			return 0
+		}
+		return f(n.Rollup)
+	})
+}
+
+func percentileField(f func(*manifest.Percentiles) int64) compiled {
+	return rollupField(func(r *manifest.Rollup) int64 {
+		if r.Size.Percentiles == nil {
+			return 0
+		}
+		return f(r.Size.Percentiles)
+	})
+}
+
+func stringField(f func(*manifest.Node) string) compiled {
+	return compiled{kind: kindString, eval: func(e *env) value { return value{s: f(e.node)} }}
+}
+
+func boolField(f func(*manifest.Node) bool) compiled {
+	return compiled{kind: kindBool, eval: func(e *env) value { return value{b: f(e.node)} }}
+}
+
+// fields are the node and rollup attributes available to expressions.
+// Rollup fields read as zero on nodes without a rollup.
+var fields = map[string]compiled{
+	"path":  stringField(func(n *manifest.Node) string { return n.Path }),
+	"name":  stringField(func(n *manifest.Node) string { return filepath.Base(n.Path) }),
+	"ext":   stringField(func(n *manifest.Node) string { return filepath.Ext(n.Path) }),
+	"dir":   stringField(func(n *manifest.Node) string { return filepath.Dir(n.Path) }),
+	"depth": intField(func(n *manifest.Node) int64 { return int64(nodeDepth(n.Path)) }),
+
+	"is_dir":  boolField(func(n *manifest.Node) bool { return n.IsDir }),
+	"is_file": boolField(func(n *manifest.Node) bool { return !n.IsDir }),
+
+	"size_bytes":          intField(func(n *manifest.Node) int64 { return n.SizeBytes }),
+	"mtime_unix":          intField(func(n *manifest.Node) int64 { return n.MtimeUnix }),
+	"inode":               intField(func(n *manifest.Node) int64 { return int64(n.Inode) }),
+	"file_count":          intField(func(n *manifest.Node) int64 { return int64(n.FileCount) }),
+	"direct_subdir_count": intField(func(n *manifest.Node) int64 { return int64(n.DirectSubdirCount) }),
+
+	"rollup.total_files":           rollupField(func(r *manifest.Rollup) int64 { return int64(r.TotalFiles) }),
+	"rollup.total_descendant_dirs": rollupField(func(r *manifest.Rollup) int64 { return int64(r.TotalDescendantDirs) }),
+	"rollup.last_modified":         rollupField(func(r *manifest.Rollup) int64 { return r.LastModified }),
+	"rollup.size.total":            rollupField(func(r *manifest.Rollup) int64 { return r.Size.Total }),
+	"rollup.size.min":              rollupField(func(r *manifest.Rollup) int64 { return r.Size.Min }),
+	"rollup.size.max":              rollupField(func(r *manifest.Rollup) int64 { return r.Size.Max }),
+	"rollup.size.mean":             rollupField(func(r *manifest.Rollup) int64 { return r.Size.Mean }),
+	"rollup.size.median":           rollupField(func(r *manifest.Rollup) int64 { return r.Size.Median }),
+	"rollup.size.percentiles.p50":  percentileField(func(p *manifest.Percentiles) int64 { return p.P50 }),
+	"rollup.size.percentiles.p90":  percentileField(func(p *manifest.Percentiles) int64 { return p.P90 }),
+	"rollup.size.percentiles.p99":  percentileField(func(p *manifest.Percentiles) int64 { return p.P99 }),
+}
+
+type function struct {
+	args []kind
+	ret  kind
+	call func(*env, []value) value
+}
+
+var funcs = map[string]function{
+	"has_prefix": {
+		args: []kind{kindString, kindString},
+		ret:  kindBool,
+		call: func(_ *env, a []value) value { return value{b: strings.HasPrefix(a[0].s, a[1].s)} },
+	},
+	"has_suffix": {
+		args: []kind{kindString, kindString},
+		ret:  kindBool,
+		call: func(_ *env, a []value) value { return value{b: strings.HasSuffix(a[0].s, a[1].s)} },
+	},
+	"contains": {
+		args: []kind{kindString, kindString},
+		ret:  kindBool,
+		call: func(_ *env, a []value) value { return value{b: strings.Contains(a[0].s, a[1].s)} },
+	},
+	// under(path, dir) is true for dir itself and everything below it.
+	"under": {
+		args: []kind{kindString, kindString},
+		ret:  kindBool,
+		call: func(_ *env, a []value) value {
+			p, dir := a[0].s, strings.TrimSuffix(a[1].s, "/")
+			return value{b: p == dir || strings.HasPrefix(p, dir+"/")}
+		},
+	},
+	// glob(pattern, s) uses path.Match syntax.
+	"glob": {
+		args: []kind{kindString, kindString},
+		ret:  kindBool,
+		call: func(_ *env, a []value) value {
+			ok, _ := path.Match(a[0].s, a[1].s)
+			return value{b: ok}
+		},
+	},
+	// ext_count(ext) reads rollup.extensions[ext].
+	"ext_count": {
+		args: []kind{kindString},
+		ret:  kindInt,
+		call: func(e *env, a []value) value {
+			if e.node.Rollup == nil {
+				return value{}
+			}
+			return value{i: int64(e.node.Rollup.Extensions[a[0].s])}
+		},
+	},
+	// count_files(glob) counts direct child files whose name matches glob.
+	"count_files": {
+		args: []kind{kindString},
+		ret:  kindInt,
+		call: func(e *env, a []value) value { return value{i: countChildren(e, a[0].s, false)} },
+	},
+	// count_dirs(glob) counts direct child directories whose name matches glob.
+	"count_dirs": {
+		args: []kind{kindString},
+		ret:  kindInt,
+		call: func(e *env, a []value) value { return value{i: countChildren(e, a[0].s, true)} },
+	},
+}
+
+func countChildren(e *env, pattern string, dirs bool) int64 {
+	var n int64
+	for _, c := range e.children[e.node.Path] {
+		if c.IsDir != dirs {
+			continue
+		}
+		if ok, _ := path.Match(pattern, filepath.Base(c.Path)); ok {
+			n++
+		}
+	}
+	return n
+}
+
+func nodeDepth(p string) int {
+	if p == "." {
+		return 0
+	}
+	return strings.Count(p, string(filepath.Separator)) + 1
+}
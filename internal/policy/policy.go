@@ -0,0 +1,156 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+// Capability is the capability name attached to policy violations.
+const Capability = "policy"
+
+// Spec is a policy as written in the `policies:` config section.
+//
+// A policy applies to every node matching Where (all nodes if empty).
+// Deny reports a violation when it evaluates to true; Require reports a
+// violation when it evaluates to false. At least one of them must be set.
+type Spec struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Severity    string `yaml:"severity"` // error (default) | warning | info | off
+	Where       string `yaml:"where"`
+	Deny        string `yaml:"deny"`
+	Require     string `yaml:"require"`
+}
+
+// Policy is a compiled Spec.
+type Policy struct {
+	Name        string
+	Description string
+	Severity    manifest.Severity
+
+	where   *Expr
+	deny    *Expr
+	require *Expr
+}
+
+// Set is a list of compiled policies evaluated together. It implements
+// manifest.Checker so policies run as part of Manifest.Validate.
+type Set []*Policy
+
+// Compile parses and type-checks every spec. Policies with severity off
+// are dropped.
+func Compile(specs []Spec) (Set, error) {
+	var set Set
+	seen := make(map[string]bool, len(specs))
+
+	for i, spec := range specs {
+		if spec.Name == "" {
+			return nil, fmt.Errorf("policy #%d: name is required", i+1)
+		}
+		if seen[spec.Name] {
+			return nil, fmt.Errorf("policy %s: duplicate name", spec.Name)
+		}
+		seen[spec.Name] = true
+
+		p, err := compileSpec(spec)
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: %w", spec.Name, err)
+		}
+		if p.Severity == manifest.SeverityOff {
+			continue
+		}
+		set = append(set, p)
+	}
+
+	return set, nil
+}
+
+func compileSpec(spec Spec) (*Policy, error) {
+	p := &Policy{
+		Name:        spec.Name,
+		Description: spec.Description,
+		Severity:    manifest.SeverityError,
+	}
+
+	if spec.Severity != "" {
+		sev, ok := manifest.ParseSeverity(spec.Severity)
+		if !ok {
+			return nil, fmt.Errorf("invalid severity %q (expected error, warning, info or off)", spec.Severity)
+		}
+		p.Severity = sev
+	}
+
+	if spec.Deny == "" && spec.Require == "" {
+		return nil, fmt.Errorf("one of deny or require must be set")
+	}
+
+	var err error
+	if spec.Where != "" {
+		if p.where, err = ParseExpr(spec.Where); err != nil {
+			return nil, fmt.Errorf("where: %w", err)
+		}
+	}
+	if spec.Deny != "" {
+		if p.deny, err = ParseExpr(spec.Deny); err != nil {
+			return nil, fmt.Errorf("deny: %w", err)
+		}
+	}
+	if spec.Require != "" {
+		if p.require, err = ParseExpr(spec.Require); err != nil {
+			return nil, fmt.Errorf("require: %w", err)
+		}
+	}
+
+	return p, nil
+}
+
+// Check evaluates every policy against every node.
+func (s Set) Check(m *manifest.Manifest) []manifest.InvariantViolation {
+	if len(s) == 0 {
+		return nil
+	}
+
+	e := &env{children: make(map[string][]*manifest.Node)}
+	parentOf := m.ParentFunc()
+	for _, n := range m.Nodes {
+		if n.Path == "." {
+			continue
+		}
+		parent := parentOf(n.Path)
+		e.children[parent] = append(e.children[parent], n)
+	}
+
+	var violations []manifest.InvariantViolation
+	for _, n := range m.Nodes {
+		e.node = n
+
+		for _, p := range s {
+			if err := p.evaluate(e); err != nil {
+				violations = append(violations, manifest.InvariantViolation{
+					Path:        n.Path,
+					Capability:  Capability,
+					Invariant:   p.Name,
+					Description: p.Description,
+					Severity:    p.Severity,
+					Err:         err,
+				})
+			}
+		}
+	}
+
+	return violations
+}
+
+func (p *Policy) evaluate(e *env) error {
+	if p.where != nil && !p.where.match(e) {
+		return nil
+	}
+	if p.deny != nil && p.deny.match(e) {
+		return fmt.Errorf("deny matched: %s", p.deny)
+	}
+	if p.require != nil && !p.require.match(e) {
+		return fmt.Errorf("require not met: %s", p.require)
+	}
+	return nil
+}
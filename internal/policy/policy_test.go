@@ -0,0 +1,115 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+func testManifest() *manifest.Manifest {
+	return &manifest.Manifest{
+		Nodes: []*manifest.Node{
+			{Path: ".", IsDir: true},
+			{Path: "assets", IsDir: true},
+			{Path: "assets/video.mp4", SizeBytes: 50 << 20},
+			{Path: "bin", IsDir: true},
+			{Path: "bin/tool", SizeBytes: 30 << 20},
+			{Path: "config", IsDir: true},
+			{Path: "config/.env", SizeBytes: 10},
+			{Path: "tests", IsDir: true},
+			{Path: "tests/api", IsDir: true},
+			{Path: "tests/api/api_test.go", SizeBytes: 100},
+			{Path: "tests/db", IsDir: true},
+			{Path: "tests/db/fixtures.sql", SizeBytes: 100},
+		},
+	}
+}
+
+func TestPolicies_Examples(t *testing.T) {
+	set, err := Compile([]Spec{
+		{
+			Name:  "no-large-files-outside-assets",
+			Where: `is_file && !under(path, "assets")`,
+			Deny:  `size_bytes > 20MB`,
+		},
+		{
+			Name:     "no-env-files",
+			Severity: "warning",
+			Deny:     `name == ".env" || glob(".env.*", name)`,
+		},
+		{
+			Name:    "tests-have-go-tests",
+			Where:   `is_dir && has_prefix(path, "tests/")`,
+			Require: `count_files("*_test.go") > 0`,
+		},
+	})
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+
+	got := map[string]string{}
+	for _, v := range set.Check(testManifest()) {
+		got[v.Invariant] = v.Path
+		if v.Capability != Capability {
+			t.Fatalf("unexpected capability %q", v.Capability)
+		}
+	}
+
+	want := map[string]string{
+		"no-large-files-outside-assets": "bin/tool",
+		"no-env-files":                  "config/.env",
+		"tests-have-go-tests":           "tests/db",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for name, path := range want {
+		if got[name] != path {
+			t.Fatalf("%s: expected violation at %q, got %q", name, path, got[name])
+		}
+	}
+}
+
+func TestPolicies_CompileErrors(t *testing.T) {
+	cases := []Spec{
+		{Name: "missing-predicate"},
+		{Name: "bad-severity", Severity: "fatal", Deny: "is_dir"},
+		{Name: "unknown-field", Deny: "owner == 1"},
+		{Name: "type-mismatch", Deny: `size_bytes > "big"`},
+		{Name: "not-bool", Deny: "size_bytes"},
+		{Name: "bad-suffix", Deny: "size_bytes > 3XB"},
+		{Name: "unclosed", Deny: "(is_dir"},
+	}
+
+	for _, spec := range cases {
+		if _, err := Compile([]Spec{spec}); err == nil {
+			t.Errorf("%s: expected compile error", spec.Name)
+		}
+	}
+}
+
+func TestPolicies_SizeOverflow(t *testing.T) {
+	_, err := Compile([]Spec{{Name: "huge", Deny: "size_bytes > 99999999TB"}})
+	if err == nil || !strings.Contains(err.Error(), "overflows") {
+		t.Fatalf("expected an overflow error, got %v", err)
+	}
+}
+
+func TestPolicies_RunThroughValidate(t *testing.T) {
+	set, err := Compile([]Spec{{Name: "no-env-files", Deny: `name == ".env"`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := testManifest().Validate(manifest.ValidateOptions{
+		Strict:   true,
+		Checkers: []manifest.Checker{set},
+	})
+	if err == nil {
+		t.Fatalf("expected fatal policy violation")
+	}
+	if report.Summary.ByCapability[Capability] != 1 {
+		t.Fatalf("unexpected summary: %+v", report.Summary)
+	}
+}
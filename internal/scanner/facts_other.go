@@ -0,0 +1,9 @@
+//go:build !unix
+
+package scanner
+
+import "io/fs"
+
+func sysFacts(fs.FileInfo) (Facts, bool) {
+	return Facts{}, false
+}
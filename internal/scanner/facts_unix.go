@@ -0,0 +1,21 @@
+//go:build unix
+
+package scanner
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+func sysFacts(info fs.FileInfo) (Facts, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return Facts{}, false
+	}
+	return Facts{
+		Inode:  uint64(stat.Ino),
+		Device: uint64(stat.Dev),
+		UID:    stat.Uid,
+		GID:    stat.Gid,
+	}, true
+}
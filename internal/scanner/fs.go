@@ -0,0 +1,50 @@
+package scanner
+
+import (
+	"io/fs"
+	"os"
+)
+
+// Facts are OS-level details that fs.FileInfo does not expose portably.
+type Facts struct {
+	Inode  uint64
+	Device uint64
+	UID    uint32
+	GID    uint32
+}
+
+// FactsFS is implemented by filesystems that can report Facts for an entry.
+// Filesystems without it (embed.FS, fstest.MapFS, ...) are scanned the same
+// way, just without inodes.
+type FactsFS interface {
+	fs.FS
+	Facts(name string, info fs.FileInfo) (Facts, bool)
+}
+
+// OSFS returns the host filesystem rooted at dir. It is what Scan uses when
+// Options.FS is nil.
+func OSFS(dir string) fs.FS {
+	return osFS{FS: os.DirFS(dir)}
+}
+
+type osFS struct {
+	fs.FS
+}
+
+func (osFS) Facts(_ string, info fs.FileInfo) (Facts, bool) {
+	return sysFacts(info)
+}
+
+// fileFS presents a single host file as the root of a filesystem, so that
+// walking "." visits just that file.
+type fileFS struct {
+	osFS
+	name string
+}
+
+func (f fileFS) Open(name string) (fs.File, error) {
+	if name != "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return f.osFS.Open(f.name)
+}
@@ -1,7 +1,7 @@
 package scanner
 
 import (
-	"os"
+	"io/fs"
     "path/filepath"
     "strings"
 
@@ -9,7 +9,7 @@ import (
 
 )
 
-func (f FilterSet) Blocked(path string, d os.DirEntry) bool {
+func (f FilterSet) Blocked(path string, d fs.DirEntry) bool {
 	base := filepath.Base(path)
 
     for _, r := range f.Block {
@@ -25,7 +25,7 @@ func (f FilterSet) Blocked(path string, d os.DirEntry) bool {
     return false
 }
 
-func matchDir(r filter.Rule, path, base string, d os.DirEntry) bool {
+func matchDir(r filter.Rule, path, base string, d fs.DirEntry) bool {
     switch r.Type {
     case filter.Basename:
         return d.IsDir() && base == r.Pattern
@@ -1,7 +1,7 @@
 package scanner
 
 import (
-	"os"
+	"io/fs"
 	"path/filepath"
 	
 	"github.com/dtnitsch/manifestor/internal/filter"
@@ -14,10 +14,16 @@ type Scanner struct {
 
 	// Explicity store skipped folders for output
     skipped map[string]manifest.SkippedEntry
+
+	// Format of the root when it is itself an archive
+	rootArchive string
 }
 
 type Options struct {
 	Root               string
+	// FS is walked instead of the host filesystem when set; Root is then
+	// only recorded as the manifest's root label.
+	FS                 fs.FS
 	MaxWorkers         int
 	FollowSymlinks     bool
 	CollectInodes      bool
@@ -25,8 +31,30 @@ type Options struct {
 	CollectFileCounts  bool
 
 	EnableRollups      bool
+
+	// ExpandArchives scans zip, jar, tar and tar.gz files as directories,
+	// nesting at most MaxArchiveDepth archives deep (DefaultMaxArchiveDepth
+	// when zero).
+	ExpandArchives     bool
+	MaxArchiveDepth    int
+
+	// Sink receives nodes as they are walked instead of collecting them in
+	// Manifest.Nodes.
+	Sink               NodeSink
 }
 
+// NodeSink consumes a scan as it happens. Begin receives the manifest's
+// metadata (root, filters, archives; no nodes) once, then WriteNode is
+// called in walk order: each directory before its descendants, and every
+// subtree contiguous.
+type NodeSink interface {
+	Begin(m *manifest.Manifest) error
+	WriteNode(n *manifest.Node) error
+}
+
+// DefaultMaxArchiveDepth bounds nested archive expansion.
+const DefaultMaxArchiveDepth = 2
+
 func New(opts Options, filters FilterSet) *Scanner {
 	return &Scanner{
 		opts:    opts,
@@ -39,7 +67,7 @@ type FilterSet struct {
 	Allow []filter.Rule
 }
 
-func (f FilterSet) MatchedRule(path string, d os.DirEntry) *filter.Rule {
+func (f FilterSet) MatchedRule(path string, d fs.DirEntry) *filter.Rule {
 	base := filepath.Base(path)
 
 	for _, r := range f.Block {
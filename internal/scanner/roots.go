@@ -0,0 +1,139 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dtnitsch/manifestor/internal/filter"
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+// Root is one named root of a multi-root scan. Filters are applied on top
+// of the scan-wide filters, with paths relative to this root.
+type Root struct {
+	Name    string
+	Path    string
+	FS      fs.FS
+	Filters FilterSet
+}
+
+// ScanRoots scans each root with opts and merges the results into one
+// manifest. Nodes and skipped entries are namespaced under the root's name,
+// and a synthetic "." directory sits above all roots so rollups aggregate
+// across them. opts.Root and opts.FS are ignored.
+//
+// A root without a Name is named after the last element of its Path.
+func ScanRoots(ctx context.Context, opts Options, filters FilterSet, roots []Root) (*manifest.Manifest, error) {
+	roots = append([]Root(nil), roots...)
+	for i := range roots {
+		if roots[i].Name == "" {
+			roots[i].Name = filepath.Base(filepath.Clean(roots[i].Path))
+		}
+	}
+	if err := checkRootNames(roots); err != nil {
+		return nil, err
+	}
+
+	m := &manifest.Manifest{Generated: time.Now().UTC()}
+	if len(filters.Block) > 0 || len(filters.Allow) > 0 {
+		m.Filters = &manifest.FilterMeta{Block: filters.Block, Allow: filters.Allow}
+	}
+	if opts.ExpandArchives {
+		m.Archives = &manifest.ArchiveMeta{MaxDepth: opts.archiveDepth()}
+	}
+	for _, r := range roots {
+		meta := manifest.RootMeta{Name: r.Name, Path: r.Path}
+		if len(r.Filters.Block) > 0 || len(r.Filters.Allow) > 0 {
+			meta.Filters = &manifest.FilterMeta{Block: r.Filters.Block, Allow: r.Filters.Allow}
+		}
+		m.Roots = append(m.Roots, meta)
+	}
+
+	top := &manifest.Node{Path: ".", IsDir: true}
+	if opts.Sink != nil {
+		if err := opts.Sink.Begin(m); err != nil {
+			return nil, fmt.Errorf("begin stream: %w", err)
+		}
+		if err := opts.Sink.WriteNode(top); err != nil {
+			return nil, fmt.Errorf("write node %q: %w", top.Path, err)
+		}
+	} else {
+		m.Nodes = append(m.Nodes, top)
+	}
+
+	for _, r := range roots {
+		o := opts
+		o.Root = r.Path
+		o.FS = r.FS
+		if opts.Sink != nil {
+			o.Sink = prefixSink{name: r.Name, next: opts.Sink}
+		}
+
+		f := FilterSet{
+			Block: append(append([]filter.Rule(nil), filters.Block...), r.Filters.Block...),
+			Allow: append(append([]filter.Rule(nil), filters.Allow...), r.Filters.Allow...),
+		}
+
+		sub, err := New(o, f).Scan(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("root %q: %w", r.Name, err)
+		}
+
+		for _, n := range sub.Nodes {
+			n.Path = filepath.Join(r.Name, n.Path)
+			m.Nodes = append(m.Nodes, n)
+		}
+		for _, s := range sub.Skipped {
+			s.Path = filepath.Join(r.Name, s.Path)
+			m.Skipped = append(m.Skipped, s)
+		}
+	}
+
+	sort.Slice(m.Skipped, func(i, j int) bool {
+		return m.Skipped[i].Path < m.Skipped[j].Path
+	})
+
+	return m, nil
+}
+
+// checkRootNames requires unique names that are usable as a single path
+// element, since they become the top-level directories of the manifest.
+func checkRootNames(roots []Root) error {
+	if len(roots) == 0 {
+		return fmt.Errorf("no roots to scan")
+	}
+
+	seen := make(map[string]bool, len(roots))
+	for _, r := range roots {
+		switch {
+		case r.Name == "", r.Name == ".", r.Name == "..":
+			return fmt.Errorf("root %q: invalid name %q", r.Path, r.Name)
+		case strings.ContainsAny(r.Name, `/\`):
+			return fmt.Errorf("root %q: name %q must not contain a path separator", r.Path, r.Name)
+		case seen[r.Name]:
+			return fmt.Errorf("duplicate root name %q", r.Name)
+		}
+		seen[r.Name] = true
+	}
+	return nil
+}
+
+// prefixSink namespaces streamed nodes under their root's name. The
+// multi-root manifest has already begun, so per-root Begin calls are
+// dropped.
+type prefixSink struct {
+	name string
+	next NodeSink
+}
+
+func (prefixSink) Begin(*manifest.Manifest) error { return nil }
+
+func (p prefixSink) WriteNode(n *manifest.Node) error {
+	n.Path = filepath.Join(p.name, n.Path)
+	return p.next.WriteNode(n)
+}
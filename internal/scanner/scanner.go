@@ -3,13 +3,15 @@ package scanner
 import (
     "context"
     "fmt"
+    "io"
+    "io/fs"
     "os"
     "path/filepath"
 	"sort"
 	"strings"
-    "syscall"
     "time"
 
+    "github.com/dtnitsch/manifestor/internal/archive"
     "github.com/dtnitsch/manifestor/internal/filter"
     "github.com/dtnitsch/manifestor/internal/manifest"
 )
@@ -20,10 +22,97 @@ func (s *Scanner) Scan(ctx context.Context) (*manifest.Manifest, error) {
         Generated: time.Now().UTC(),
     }
 
+	if len(s.filters.Block) > 0 || len(s.filters.Allow) > 0 {
+		m.Filters = &manifest.FilterMeta{
+			Block: s.filters.Block,
+			Allow: s.filters.Allow,
+		}
+	}
+
 	s.skipped = make(map[string]manifest.SkippedEntry)
 
-    err := filepath.WalkDir(s.opts.Root, func(path string, d os.DirEntry, err error) error {
-		norm := s.normalizePath(path)
+	fsys, rootArchive, err := s.rootFS()
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := fsys.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	if s.opts.ExpandArchives {
+		m.Archives = &manifest.ArchiveMeta{MaxDepth: s.opts.archiveDepth()}
+	}
+
+	depth := 0
+	if rootArchive != "" {
+		depth = 1
+	}
+	s.rootArchive = rootArchive
+
+	if s.opts.Sink != nil {
+		if err := s.opts.Sink.Begin(m); err != nil {
+			return nil, fmt.Errorf("begin stream: %w", err)
+		}
+	}
+
+	err = s.walk(ctx, m, fsys, "", depth)
+    if err != nil {
+        return nil, err
+    }
+
+	// Cleanup of skipped things
+	if len(s.skipped) > 0 {
+		// Adding skip details for output
+		for _, s := range s.skipped {
+			m.Skipped = append(m.Skipped, s)
+		}
+
+		// Sort Skipped deterministically
+		sort.Slice(m.Skipped, func(i, j int) bool {
+			return m.Skipped[i].Path < m.Skipped[j].Path
+		})
+	}
+
+	return m, nil
+}
+
+// rootFS returns the filesystem to walk. When archive expansion is on and
+// Root names an archive file, the archive itself becomes the root and its
+// format is returned.
+func (s *Scanner) rootFS() (fs.FS, string, error) {
+	if s.opts.FS != nil {
+		return s.opts.FS, "", nil
+	}
+
+	info, err := os.Stat(s.opts.Root)
+	if err != nil {
+		return nil, "", fmt.Errorf("root %q: %w", s.opts.Root, err)
+	}
+	if info.IsDir() {
+		return OSFS(s.opts.Root), "", nil
+	}
+
+	dir, name := filepath.Dir(s.opts.Root), filepath.Base(s.opts.Root)
+	if format := archive.Format(name); s.opts.ExpandArchives && format != "" && info.Mode().IsRegular() {
+		afs, err := archive.Open(OSFS(dir), name, s.keepMember(1))
+		if err != nil {
+			return nil, "", fmt.Errorf("archive %q: %w", s.opts.Root, err)
+		}
+		return afs, format, nil
+	}
+
+	// Any other file is scanned as a one-node manifest.
+	return fileFS{osFS: osFS{FS: os.DirFS(dir)}, name: name}, "", nil
+}
+
+// walk records the nodes of fsys. prefix is the node path of the archive
+// fsys was opened from ("" for the scan root) and depth the number of
+// archives entered to get here.
+func (s *Scanner) walk(ctx context.Context, m *manifest.Manifest, fsys fs.FS, prefix string, depth int) error {
+	factsFS, hasFacts := fsys.(FactsFS)
+
+    return fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		norm := memberPath(prefix, path)
 
         if err != nil {
             return fmt.Errorf("walk %q: %w", norm, err)
@@ -36,6 +125,11 @@ func (s *Scanner) Scan(ctx context.Context) (*manifest.Manifest, error) {
         default:
         }
 
+		// The archive node itself was recorded by the enclosing walk
+		if prefix != "" && path == "." {
+			return nil
+		}
+
         // Apply filters
         if s.filters.Blocked(norm, d) {
 			s.recordSkip(norm, d, "blocked by filter", s.filters.MatchedRule(norm, d))
@@ -55,19 +149,25 @@ func (s *Scanner) Scan(ctx context.Context) (*manifest.Manifest, error) {
             Path:  norm,
             IsDir: d.IsDir(),
         }
+		if prefix == "" && path == "." {
+			node.Archive = s.rootArchive
+		}
 
 		// Statistics
 		if !d.IsDir() {
 			node.SizeBytes = info.Size()
 		}
 
-        if s.opts.CollectTimestamps {
+		// Synthesized archive directories carry no time
+        if s.opts.CollectTimestamps && !info.ModTime().IsZero() {
             node.MtimeUnix = info.ModTime().Unix()
         }
 
         if s.opts.CollectInodes {
-            if stat, ok := info.Sys().(*syscall.Stat_t); ok {
-                node.Inode = stat.Ino
+            if hasFacts {
+                if facts, ok := factsFS.Facts(path, info); ok {
+                    node.Inode = facts.Inode
+                }
             }
         }
 
@@ -82,31 +182,57 @@ func (s *Scanner) Scan(ctx context.Context) (*manifest.Manifest, error) {
         }
 		*/
 
-        m.Nodes = append(m.Nodes, node)
-        return nil
-    })
+		// Archives past the depth limit stay plain files
+		format := archive.Format(path)
+		if s.opts.ExpandArchives && format != "" && info.Mode().IsRegular() && depth < s.opts.archiveDepth() {
+			afs, err := archive.Open(fsys, path, s.keepMember(depth+1))
+			if err != nil {
+				return fmt.Errorf("archive %q: %w", norm, err)
+			}
+
+			node.IsDir = true
+			node.Archive = format
+			if err := s.emit(m, node); err != nil {
+				return err
+			}
+
+			// Zip members are read from the archive file while walking.
+			defer afs.Close()
+			return s.walk(ctx, m, afs, norm, depth+1)
+		}
 
-    if err != nil {
-        return nil, err
-    }
+        return s.emit(m, node)
+    })
+}
 
-	// Cleanup of skipped things
-	if len(s.skipped) > 0 {
-		// Adding skip details for output
-		for _, s := range s.skipped {
-			m.Skipped = append(m.Skipped, s)
+func (s *Scanner) emit(m *manifest.Manifest, n *manifest.Node) error {
+	if s.opts.Sink != nil {
+		if err := s.opts.Sink.WriteNode(n); err != nil {
+			return fmt.Errorf("write node %q: %w", n.Path, err)
 		}
+		return nil
+	}
 
-		// Sort Skipped deterministically
-		sort.Slice(m.Skipped, func(i, j int) bool {
-			return m.Skipped[i].Path < m.Skipped[j].Path
-		})
+	m.Nodes = append(m.Nodes, n)
+	return nil
+}
+
+func (o Options) archiveDepth() int {
+	if o.MaxArchiveDepth <= 0 {
+		return DefaultMaxArchiveDepth
 	}
+	return o.MaxArchiveDepth
+}
 
-	return m, nil
+// keepMember reports which members of an archive at the given depth must be
+// held in memory: only archives the walk will open in turn.
+func (s *Scanner) keepMember(depth int) func(string) bool {
+	return func(name string) bool {
+		return depth < s.opts.archiveDepth() && archive.Format(name) != ""
+	}
 }
 
-func (s *Scanner) recordSkip(path string, d os.DirEntry, reason string, rule *filter.Rule) {
+func (s *Scanner) recordSkip(path string, d fs.DirEntry, reason string, rule *filter.Rule) {
 	entry := manifest.SkippedEntry{
 		Path:   path,
 		IsDir:  d.IsDir(),
@@ -121,21 +247,15 @@ func (s *Scanner) recordSkip(path string, d os.DirEntry, reason string, rule *fi
 	s.skipped[path] = entry
 }
 
-func (s *Scanner) normalizePath(path string) string {
-	// Convert to relative path
-	rel, err := filepath.Rel(s.opts.Root, path)
-	if err != nil {
-		// Extremely defensive: fallback to original
-		return filepath.Clean(path)
+// memberPath turns a slash-separated fs.FS name into the OS-separated,
+// root-relative form used for node paths. Names inside an archive are
+// joined to the archive's node path with manifest.ArchiveSep.
+func memberPath(prefix, name string) string {
+	norm := filepath.Clean(filepath.FromSlash(name))
+	if prefix == "" {
+		return norm
 	}
-
-	rel = filepath.Clean(rel)
-
-	if rel == "" {
-		return "."
-	}
-
-	return rel
+	return filepath.Join(prefix+manifest.ArchiveSep, norm)
 }
 
 func (s *Scanner) isSkipped(path string) bool {
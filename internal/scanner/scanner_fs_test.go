@@ -0,0 +1,340 @@
+package scanner_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/dtnitsch/manifestor/internal/filter"
+	"github.com/dtnitsch/manifestor/internal/manifest"
+	"github.com/dtnitsch/manifestor/internal/scanner"
+)
+
+func mapFS() fstest.MapFS {
+	mtime := time.Unix(1700000000, 0)
+	return fstest.MapFS{
+		"README.md":           {Data: []byte("hello"), ModTime: mtime},
+		"src/main.go":         {Data: []byte("package main\n"), ModTime: mtime},
+		"src/util/strings.go": {Data: []byte("package util\n"), ModTime: mtime},
+		"node_modules/x/a.js": {Data: []byte("x"), ModTime: mtime},
+	}
+}
+
+func nodesByPath(m *manifest.Manifest) map[string]*manifest.Node {
+	out := make(map[string]*manifest.Node, len(m.Nodes))
+	for _, n := range m.Nodes {
+		out[n.Path] = n
+	}
+	return out
+}
+
+func TestScanMapFS(t *testing.T) {
+	s := scanner.New(scanner.Options{
+		Root:              "fixture",
+		FS:                mapFS(),
+		CollectInodes:     true,
+		CollectTimestamps: true,
+	}, scanner.FilterSet{
+		Block: []filter.Rule{{Type: filter.Basename, Pattern: "node_modules"}},
+	})
+
+	m, err := s.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if m.Root != "fixture" {
+		t.Errorf("root = %q, want fixture", m.Root)
+	}
+
+	nodes := nodesByPath(m)
+	for _, p := range []string{".", "README.md", "src", filepath.Join("src", "util", "strings.go")} {
+		if _, ok := nodes[p]; !ok {
+			t.Errorf("missing node %q", p)
+		}
+	}
+	if _, ok := nodes["node_modules"]; ok {
+		t.Errorf("blocked directory was scanned")
+	}
+	if len(m.Skipped) != 1 || m.Skipped[0].Path != "node_modules" {
+		t.Errorf("skipped = %+v", m.Skipped)
+	}
+
+	readme := nodes["README.md"]
+	if readme.SizeBytes != 5 || readme.MtimeUnix != 1700000000 {
+		t.Errorf("README.md = %+v", readme)
+	}
+	if readme.Inode != 0 {
+		t.Errorf("inode = %d from a filesystem without facts", readme.Inode)
+	}
+
+	if err := m.BuildRollups(manifest.RollupOptions{EnableDirCounts: true, EnableSizeBytes: true}); err != nil {
+		t.Fatalf("rollups: %v", err)
+	}
+	if r := nodes["src"].Rollup; r == nil || r.TotalFiles != 1 || r.TotalDescendantDirs != 1 {
+		t.Errorf("src rollup = %+v", r)
+	}
+}
+
+// inodeFS reports a fake inode for every entry.
+type inodeFS struct {
+	fstest.MapFS
+	inodes map[string]uint64
+}
+
+func (f inodeFS) Facts(name string, _ fs.FileInfo) (scanner.Facts, bool) {
+	ino, ok := f.inodes[name]
+	return scanner.Facts{Inode: ino}, ok
+}
+
+func TestScanFactsFS(t *testing.T) {
+	fsys := inodeFS{
+		MapFS:  mapFS(),
+		inodes: map[string]uint64{"src/main.go": 42},
+	}
+
+	m, err := scanner.New(scanner.Options{FS: fsys, CollectInodes: true}, scanner.FilterSet{}).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	nodes := nodesByPath(m)
+	if got := nodes[filepath.Join("src", "main.go")].Inode; got != 42 {
+		t.Errorf("inode = %d, want 42", got)
+	}
+	if got := nodes["README.md"].Inode; got != 0 {
+		t.Errorf("README.md inode = %d, want 0", got)
+	}
+}
+
+func zipOf(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func tarGzOf(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, data := range files {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: time.Unix(1600000000, 0)}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestScanArchives(t *testing.T) {
+	deep := zipOf(t, map[string][]byte{"bomb.txt": []byte("boom")})
+	inner := zipOf(t, map[string][]byte{"x.txt": []byte("x"), "deep.zip": deep})
+	release := tarGzOf(t, map[string][]byte{
+		"bin/app":   []byte("binary"),
+		"inner.jar": inner,
+	})
+	fsys := fstest.MapFS{"dist/rel.tar.gz": {Data: release}}
+
+	m, err := scanner.New(scanner.Options{FS: fsys, CollectTimestamps: true, ExpandArchives: true}, scanner.FilterSet{}).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if m.Archives == nil || m.Archives.MaxDepth != scanner.DefaultMaxArchiveDepth {
+		t.Errorf("archives meta = %+v", m.Archives)
+	}
+
+	nodes := nodesByPath(m)
+	rel := nodes[filepath.Join("dist", "rel.tar.gz")]
+	if rel == nil || !rel.IsDir || rel.Archive != "tar.gz" || rel.SizeBytes != int64(len(release)) {
+		t.Fatalf("archive node = %+v", rel)
+	}
+
+	app := nodes[filepath.Join("dist", "rel.tar.gz!", "bin", "app")]
+	if app == nil || app.SizeBytes != 6 || app.MtimeUnix != 1600000000 {
+		t.Errorf("member node = %+v", app)
+	}
+	if n := nodes[filepath.Join("dist", "rel.tar.gz!", "inner.jar!", "x.txt")]; n == nil {
+		t.Errorf("nested archive member missing")
+	}
+
+	// The third level is past the default depth and stays a plain file.
+	deepNode := nodes[filepath.Join("dist", "rel.tar.gz!", "inner.jar!", "deep.zip")]
+	if deepNode == nil || deepNode.IsDir || deepNode.Archive != "" {
+		t.Errorf("deep.zip = %+v", deepNode)
+	}
+
+	if err := m.BuildRollups(manifest.RollupOptions{EnableDirCounts: true}); err != nil {
+		t.Fatalf("rollups: %v", err)
+	}
+	if r := rel.Rollup; r == nil || r.TotalDescendantDirs != 2 {
+		t.Errorf("archive rollup = %+v", r)
+	}
+	if r := nodes[filepath.Join("dist", "rel.tar.gz!", "inner.jar")].Rollup; r == nil || r.TotalFiles != 2 {
+		t.Errorf("nested archive rollup = %+v", r)
+	}
+}
+
+func TestScanArchiveRoot(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "app.zip")
+	if err := os.WriteFile(root, zipOf(t, map[string][]byte{"a/b.txt": []byte("b")}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := scanner.New(scanner.Options{Root: root, ExpandArchives: true}, scanner.FilterSet{}).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	nodes := nodesByPath(m)
+	if nodes["."] == nil || nodes["."].Archive != "zip" {
+		t.Errorf("root node = %+v", nodes["."])
+	}
+	if nodes[filepath.Join("a", "b.txt")] == nil {
+		t.Errorf("member missing: %v", m.Nodes)
+	}
+}
+
+// Members of an on-disk zip are read from the file after archive.Open
+// returns, so the file must stay open while the walk descends.
+func TestScanNestedArchiveOnDisk(t *testing.T) {
+	root := t.TempDir()
+	inner := zipOf(t, map[string][]byte{"Main.class": []byte("cafebabe")})
+	if err := os.WriteFile(filepath.Join(root, "app.zip"), zipOf(t, map[string][]byte{"lib/inner.jar": inner}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := scanner.New(scanner.Options{Root: root, ExpandArchives: true}, scanner.FilterSet{}).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	nodes := nodesByPath(m)
+	jar := nodes[filepath.Join("app.zip!", "lib", "inner.jar")]
+	if jar == nil || jar.Archive != "zip" {
+		t.Errorf("inner.jar = %+v", jar)
+	}
+	if n := nodes[filepath.Join("app.zip!", "lib", "inner.jar!", "Main.class")]; n == nil || n.SizeBytes != 8 {
+		t.Errorf("Main.class = %+v", n)
+	}
+}
+
+func TestScanRoots(t *testing.T) {
+	services := fstest.MapFS{
+		"api/main.go":   {Data: []byte("package main")},
+		"api/vendor/x":  {Data: []byte("x")},
+		"web/index.htm": {Data: []byte("<html>")},
+	}
+	libs := fstest.MapFS{
+		"log/log.go":   {Data: []byte("package log")},
+		"vendor/y.go":  {Data: []byte("package y")},
+		"dist/big.bin": {Data: []byte("0123456789")},
+	}
+
+	m, err := scanner.ScanRoots(context.Background(), scanner.Options{}, scanner.FilterSet{
+		Block: []filter.Rule{{Type: filter.Basename, Pattern: "vendor"}},
+	}, []scanner.Root{
+		{Name: "services", Path: "/mnt/a/services", FS: services},
+		{Path: "/mnt/b/libs", FS: libs, Filters: scanner.FilterSet{
+			Block: []filter.Rule{{Type: filter.Path, Pattern: "dist"}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	if len(m.Roots) != 2 || m.Roots[1].Name != "libs" || m.Roots[1].Filters == nil {
+		t.Fatalf("roots = %+v", m.Roots)
+	}
+
+	nodes := nodesByPath(m)
+	for _, p := range []string{".", "services", "libs", filepath.Join("services", "api", "main.go"), filepath.Join("libs", "log", "log.go")} {
+		if _, ok := nodes[p]; !ok {
+			t.Errorf("missing node %q", p)
+		}
+	}
+
+	var skipped []string
+	for _, s := range m.Skipped {
+		skipped = append(skipped, s.Path)
+	}
+	want := []string{filepath.Join("libs", "dist"), filepath.Join("libs", "vendor"), filepath.Join("services", "api", "vendor")}
+	if len(skipped) != len(want) {
+		t.Fatalf("skipped = %v, want %v", skipped, want)
+	}
+	for i := range want {
+		if skipped[i] != want[i] {
+			t.Errorf("skipped[%d] = %q, want %q", i, skipped[i], want[i])
+		}
+	}
+
+	if err := m.BuildRollups(manifest.RollupOptions{EnableDirCounts: true}); err != nil {
+		t.Fatalf("rollups: %v", err)
+	}
+	svc, lib := nodes["services"].Rollup, nodes["libs"].Rollup
+	if svc == nil || svc.TotalDescendantDirs != 2 || lib == nil || lib.TotalDescendantDirs != 1 {
+		t.Fatalf("root rollups = %+v, %+v", svc, lib)
+	}
+	if r := nodes["."].Rollup; r == nil || r.TotalDescendantDirs < svc.TotalDescendantDirs+lib.TotalDescendantDirs+2 {
+		t.Errorf("aggregate rollup = %+v", r)
+	}
+}
+
+func TestScanRootsRejectsDuplicateNames(t *testing.T) {
+	_, err := scanner.ScanRoots(context.Background(), scanner.Options{}, scanner.FilterSet{}, []scanner.Root{
+		{Path: "/a/src", FS: fstest.MapFS{}},
+		{Path: "/b/src", FS: fstest.MapFS{}},
+	})
+	if err == nil {
+		t.Fatal("expected duplicate root name error")
+	}
+}
+
+func TestScanFileRoot(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(root, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := scanner.New(scanner.Options{Root: root, CollectTimestamps: true}, scanner.FilterSet{}).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if len(m.Nodes) != 1 {
+		t.Fatalf("nodes = %+v, want just the file", m.Nodes)
+	}
+	if n := m.Nodes[0]; n.Path != "." || n.IsDir || n.SizeBytes != 5 || n.MtimeUnix == 0 {
+		t.Errorf("node = %+v", n)
+	}
+}
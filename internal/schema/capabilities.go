@@ -0,0 +1,95 @@
+package schema
+
+import "sort"
+
+// capabilityRequirements mirrors the presence guarantees of the rollup
+// capability invariants (see docs/capabilities.md). Each entry applies to
+// every directory rollup when the capability is declared. Ordering and sum
+// invariants cannot be expressed in JSON Schema; `manifestor validate`
+// still checks those.
+var capabilityRequirements = map[string]func() Schema{
+	"size_stats": func() Schema {
+		return Schema{
+			"properties": Schema{"size": Schema{"required": []string{"total"}}},
+			"if":         Schema{"properties": Schema{"total_files": Schema{"minimum": 1}}},
+			"then": Schema{"properties": Schema{"size": Schema{
+				"required":   []string{"total", "min", "max", "mean", "median"},
+				"properties": Schema{"total": Schema{"minimum": 1}},
+			}}},
+		}
+	},
+	"size_percentiles": func() Schema {
+		return Schema{"properties": Schema{"size": Schema{"required": []string{"percentiles"}}}}
+	},
+	"size_buckets": func() Schema {
+		return Schema{"properties": Schema{"size": Schema{"required": []string{"buckets"}}}}
+	},
+	"activity_span": func() Schema {
+		return Schema{
+			"required":   []string{"last_modified"},
+			"properties": Schema{"last_modified": Schema{"minimum": 1}},
+		}
+	},
+	"extension_counts": func() Schema {
+		return Schema{"required": []string{"extensions"}}
+	},
+}
+
+// capabilityRules builds one if/then rule per capability: if the manifest
+// declares it, every node's rollup must satisfy the requirement. then maps a
+// capability to the constraint that applies it to every node of a layout.
+func capabilityRules(then func(name string) Schema) []any {
+	names := make([]string, 0, len(capabilityRequirements))
+	for name := range capabilityRequirements {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rules := make([]any, 0, len(names))
+	for _, name := range names {
+		rules = append(rules, Schema{
+			"if": Schema{
+				"required": []string{"manifest"},
+				"properties": Schema{"manifest": Schema{
+					"required": []string{"capabilities"},
+					"properties": Schema{"capabilities": Schema{
+						"required": []string{"rollup"},
+						"properties": Schema{"rollup": Schema{
+							"required":   []string{name},
+							"properties": Schema{name: Schema{"const": true}},
+						}},
+					}},
+				}},
+			},
+			"then": then(name),
+		})
+	}
+	return rules
+}
+
+// flatCapability applies a capability requirement to every entry of nodes.
+func flatCapability(name string) Schema {
+	return Schema{
+		"properties": Schema{"nodes": Schema{
+			"items": Schema{
+				"properties": Schema{"rollup": capabilityRequirements[name]()},
+			},
+		}},
+	}
+}
+
+// treeCapability applies a capability requirement to every node of tree,
+// through a recursive definition registered in defs.
+func treeCapability(defs map[string]any) func(name string) Schema {
+	return func(name string) Schema {
+		def := "TreeCapability_" + name
+		ref := Schema{"$ref": "#/$defs/" + def}
+		defs[def] = Schema{
+			"properties": Schema{
+				"rollup":   capabilityRequirements[name](),
+				"children": Schema{"additionalProperties": ref},
+			},
+		}
+		return Schema{"properties": Schema{"tree": Schema{"additionalProperties": ref}}}
+	}
+}
@@ -0,0 +1,224 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema document. A plain map keeps the generator small
+// and the output order stable (encoding/json sorts map keys).
+type Schema = map[string]any
+
+// SupportedVersions lists the manifest versions a schema can be generated for.
+// Schemas are generated from the current Go types, so only the current
+// version is available.
+var SupportedVersions = []string{manifest.CurrentVersion}
+
+// Generate builds the JSON Schema for a manifest version from the
+// manifest.Manifest, Node, Rollup and ManifestMeta types, including the
+// requirements implied by declared rollup capabilities. Documents whose
+// schema.layout is manifest.TreeLayout or manifest.CompactLayout are checked
+// against manifest.TreeManifest or manifest.CompactManifest instead; compact
+// documents drop zero values, so capability requirements are not applied to
+// them.
+func Generate(version string) (Schema, error) {
+	if version != manifest.CurrentVersion {
+		return nil, fmt.Errorf("no schema for manifest version %s (supported: %s)",
+			version, strings.Join(SupportedVersions, ", "))
+	}
+
+	g := &generator{defs: make(map[string]any)}
+	flat := g.structSchema(reflect.TypeOf(manifest.Manifest{}))
+	flat["allOf"] = capabilityRules(flatCapability)
+
+	tree := Schema{
+		"$ref":  g.typeSchema(reflect.TypeOf(manifest.TreeManifest{}))["$ref"],
+		"allOf": capabilityRules(treeCapability(g.defs)),
+	}
+	compact := g.typeSchema(reflect.TypeOf(manifest.CompactManifest{}))
+
+	pinVersions(g.defs)
+	describeCompact(g.defs)
+
+	return Schema{
+		"$schema": draft,
+		"$id":     "urn:manifestor:schema:manifest:" + version,
+		"title":   "manifestor manifest v" + version,
+		"$defs":   g.defs,
+		"if":      layoutIs(manifest.TreeLayout),
+		"then":    tree,
+		"else": Schema{
+			"if":   layoutIs(manifest.CompactLayout),
+			"then": compact,
+			"else": flat,
+		},
+	}, nil
+}
+
+// layoutIs matches documents whose manifest.schema.layout is layout.
+func layoutIs(layout string) Schema {
+	return Schema{
+		"required": []string{"manifest"},
+		"properties": Schema{"manifest": Schema{
+			"required": []string{"schema"},
+			"properties": Schema{"schema": Schema{
+				"required":   []string{"layout"},
+				"properties": Schema{"layout": Schema{"const": layout}},
+			}},
+		}},
+	}
+}
+
+// Marshal renders a schema as indented JSON.
+func Marshal(s Schema) ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+type generator struct {
+	defs map[string]any
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *generator) typeSchema(t reflect.Type) Schema {
+	switch {
+	case t == timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Pointer:
+		return g.typeSchema(t.Elem())
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		if _, ok := g.defs[t.Name()]; !ok {
+			g.defs[t.Name()] = nil // reserve to break cycles
+			g.defs[t.Name()] = g.structSchema(t)
+		}
+		return Schema{"$ref": "#/$defs/" + t.Name()}
+	case reflect.Slice, reflect.Array:
+		return Schema{"type": "array", "items": g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": g.typeSchema(t.Elem())}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Schema{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	default:
+		return Schema{}
+	}
+}
+
+// structSchema maps exported fields by their json tags. Fields without
+// omitempty are required; nil slices and maps encode as null, so those
+// also accept null.
+func (g *generator) structSchema(t reflect.Type) Schema {
+	props := make(map[string]any)
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		omitempty := strings.Contains(opts, "omitempty")
+
+		fs := g.typeSchema(f.Type)
+		if !omitempty && (f.Type.Kind() == reflect.Slice || f.Type.Kind() == reflect.Map) {
+			fs["type"] = []string{fs["type"].(string), "null"}
+		}
+		props[name] = fs
+
+		if !omitempty {
+			required = append(required, name)
+		}
+	}
+
+	s := Schema{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// pinVersions fixes the version and schema markers to the values this
+// build writes.
+func pinVersions(defs map[string]any) {
+	meta := defs["ManifestMeta"].(Schema)
+	props := meta["properties"].(map[string]any)
+	props["version"] = Schema{"const": manifest.CurrentVersion}
+
+	schemaMeta := defs["SchemaMeta"].(Schema)
+	sp := schemaMeta["properties"].(map[string]any)
+	sp["node"] = Schema{"const": manifest.NodeSchema}
+	sp["rollup"] = Schema{"const": manifest.RollupSchema}
+	sp["layout"] = Schema{"enum": []string{manifest.TreeLayout, manifest.CompactLayout}}
+
+	compact := defs["CompactMeta"].(Schema)
+	cp := compact["properties"].(map[string]any)
+	cp["version"] = Schema{"const": manifest.CurrentVersion}
+
+	caps := defs["CompactCapabilities"].(Schema)
+	caps["properties"].(map[string]any)["rollup"] = Schema{
+		"type":        "array",
+		"items":       Schema{"enum": manifest.RollupCapabilityNames()},
+		"uniqueItems": true,
+	}
+}
+
+// compactDescriptions explains the fields a compact document encodes
+// differently from the flat layout.
+var compactDescriptions = map[string]map[string]string{
+	"CompactManifest": {
+		"mtime_epoch": "Unix time that mtime_delta and last_modified_delta are relative to",
+		"prefixes":    "Path prefixes shared by several nodes; node.prefix is a 1-based index into this list",
+		"nodes":       "Nodes in flat-layout order, with zero-valued fields omitted",
+	},
+	"CompactNode": {
+		"prefix":      "1-based index into prefixes; the node path is prefixes[prefix-1] + name, or name when absent",
+		"name":        "Node path after its prefix",
+		"mtime_delta": "mtime_unix - mtime_epoch; absent when the node has no mtime",
+	},
+	"CompactRollup": {
+		"last_modified_delta": "last_modified - mtime_epoch; absent when last_modified is 0",
+	},
+	"CompactCapabilities": {
+		"rollup": "Declared rollup capabilities; undeclared ones are omitted",
+	},
+}
+
+func describeCompact(defs map[string]any) {
+	for def, fields := range compactDescriptions {
+		props := defs[def].(Schema)["properties"].(map[string]any)
+		for name, desc := range fields {
+			props[name].(Schema)["description"] = desc
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package schema_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+	"github.com/dtnitsch/manifestor/internal/output"
+	"github.com/dtnitsch/manifestor/internal/scanner"
+	"github.com/dtnitsch/manifestor/internal/schema"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"gopkg.in/yaml.v3"
+)
+
+func compile(t *testing.T) *jsonschema.Schema {
+	t.Helper()
+
+	s, err := schema.Generate(manifest.CurrentVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := schema.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("manifest.schema.json", doc); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("manifest.schema.json")
+	if err != nil {
+		t.Fatalf("generated schema does not compile: %v", err)
+	}
+	return sch
+}
+
+// scanFixture produces a manifest the same way main.go does.
+func scanFixture(t *testing.T) *manifest.Manifest {
+	t.Helper()
+
+	root := t.TempDir()
+	for path, content := range map[string]string{
+		"README.md":      "# hello",
+		"src/main.go":    "package main",
+		"src/util/a.go":  "package util // a",
+		"src/util/b.go":  "package util // bb",
+		"docs/guide.txt": "guide",
+	} {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(root, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	sc := scanner.New(scanner.Options{Root: root, CollectInodes: true, CollectTimestamps: true}, scanner.FilterSet{})
+	m, err := sc.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.BuildRollups(manifest.RollupOptions{
+		EnableDirCounts:   true,
+		EnableSizeBytes:   true,
+		EnableFileTypes:   true,
+		EnablePercentiles: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caps := m.Manifest.Capabilities
+	m.Manifest = manifest.DefaultManifestMeta()
+	m.Manifest.Capabilities = caps
+	return m
+}
+
+func TestSchemaArtifactUpToDate(t *testing.T) {
+	s, err := schema.Generate(manifest.CurrentVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := schema.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join("..", "..", "docs", "schema", "manifest-"+manifest.CurrentVersion+".schema.json")
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("%s is stale; regenerate with: manifestor schema -o %s", path, path)
+	}
+}
+
+func TestWriterOutputConforms(t *testing.T) {
+	sch := compile(t)
+	m := scanFixture(t)
+	dir := t.TempDir()
+
+	jsonPath := filepath.Join(dir, "manifest.json")
+	if err := output.WriteJSON(jsonPath, m); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate(inst); err != nil {
+		t.Fatalf("json output does not conform: %v", err)
+	}
+
+	yamlPath := filepath.Join(dir, "manifest.yaml")
+	if err := output.WriteYAML(yamlPath, m); err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(yamlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	asJSON, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst, err = jsonschema.UnmarshalJSON(bytes.NewReader(asJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate(inst); err != nil {
+		t.Fatalf("yaml output does not conform: %v", err)
+	}
+}
+
+func TestCapabilityConditionalRequirements(t *testing.T) {
+	sch := compile(t)
+	m := scanFixture(t)
+
+	// Drop size.min from a directory that has files while size_stats is declared.
+	for _, n := range m.Nodes {
+		if n.Path == "src" {
+			n.Rollup.Size.Min = 0
+		}
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate(inst); err == nil {
+		t.Fatalf("expected size_stats requirement to reject missing size.min")
+	}
+
+	// Without the capability the same document is acceptable.
+	m.Manifest.Capabilities.Rollup.SizeStats = false
+	data, _ = json.Marshal(m)
+	inst, _ = jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err := sch.Validate(inst); err != nil {
+		t.Fatalf("expected document without size_stats to conform: %v", err)
+	}
+}
+
+func TestTreeLayoutConforms(t *testing.T) {
+	sch := compile(t)
+	m := scanFixture(t)
+
+	tree, err := m.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate(inst); err != nil {
+		t.Fatalf("tree layout does not conform: %v", err)
+	}
+
+	// Capability requirements reach nested directories too.
+	tree.Tree["."].Children["src"].Children["util"].Rollup.Size.Min = 0
+	data, _ = json.Marshal(tree)
+	inst, _ = jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err := sch.Validate(inst); err == nil {
+		t.Fatalf("expected size_stats requirement to reject missing size.min in the tree")
+	}
+}
+
+func TestCompactLayoutConforms(t *testing.T) {
+	sch := compile(t)
+	m := scanFixture(t)
+
+	data, err := json.Marshal(m.Compact())
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate(inst); err != nil {
+		t.Fatalf("compact layout does not conform: %v", err)
+	}
+}
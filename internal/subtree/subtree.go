@@ -0,0 +1,296 @@
+// Package subtree splits manifests into per-subtree manifests and merges
+// sibling manifests back together. Rollups are rebuilt for every result so
+// each manifest is internally consistent.
+package subtree
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+)
+
+// Top is the Dir of the part holding everything above the split depth.
+const Top = "."
+
+// Part is one manifest produced by Split.
+type Part struct {
+	// Dir is the subtree's root node path, or Top.
+	Dir      string
+	Manifest *manifest.Manifest
+}
+
+// Split cuts m at the directories exactly depth levels below the root. Each
+// such directory and its descendants become one part; nodes above them
+// (including files at the split depth) form the Top part. Node paths are
+// kept as-is so the parts can be merged back with Merge.
+func Split(m *manifest.Manifest, depth int) ([]Part, error) {
+	if depth < 1 {
+		return nil, fmt.Errorf("split depth must be at least 1, got %d", depth)
+	}
+
+	cuts := make(map[string]bool)
+	for _, n := range m.Nodes {
+		if n.IsDir && components(n.Path) == depth {
+			cuts[n.Path] = true
+		}
+	}
+
+	// A node belongs to its nearest cut ancestor. Walking parents, rather
+	// than path components, keeps archive members with their archive.
+	parentOf := m.ParentFunc()
+	owner := func(path string) string {
+		for p := path; p != "."; {
+			if cuts[p] {
+				return p
+			}
+			next := parentOf(p)
+			if next == p {
+				break
+			}
+			p = next
+		}
+		return Top
+	}
+
+	byDir := map[string]*manifest.Manifest{Top: emptyLike(m)}
+	for dir := range cuts {
+		byDir[dir] = emptyLike(m)
+	}
+
+	for _, n := range m.Nodes {
+		part := byDir[owner(n.Path)]
+		part.Nodes = append(part.Nodes, copyNode(n))
+	}
+	for _, s := range m.Skipped {
+		part := byDir[owner(s.Path)]
+		part.Skipped = append(part.Skipped, s)
+	}
+
+	opts, hasRollups := rollupOptions(m)
+
+	dirs := make([]string, 0, len(byDir))
+	for dir := range byDir {
+		dirs = append(dirs, dir)
+	}
+	sort.Slice(dirs, func(i, j int) bool { return lessPath(dirs[i], dirs[j]) })
+
+	out := make([]Part, 0, len(dirs))
+	for _, dir := range dirs {
+		pm := byDir[dir]
+		if hasRollups {
+			if err := pm.BuildRollups(opts); err != nil {
+				return nil, fmt.Errorf("rollups for %s: %w", dir, err)
+			}
+		}
+		out = append(out, Part{Dir: dir, Manifest: pm})
+	}
+	return out, nil
+}
+
+// Merge combines sibling manifests into one. Manifests scanned from
+// different roots are rebased under their common parent directory; missing
+// ancestor directories are synthesized and rollups rebuilt over the result.
+// A path present in more than one input is an error.
+func Merge(ms ...*manifest.Manifest) (*manifest.Manifest, error) {
+	if len(ms) == 0 {
+		return nil, fmt.Errorf("nothing to merge")
+	}
+
+	first := ms[0]
+	for _, m := range ms[1:] {
+		if m.Manifest.Version != first.Manifest.Version || m.Manifest.Schema != first.Manifest.Schema {
+			return nil, fmt.Errorf("cannot merge manifest version %s (%s) with %s (%s)",
+				first.Manifest.Version, first.Manifest.Schema.Node, m.Manifest.Version, m.Manifest.Schema.Node)
+		}
+		if !reflect.DeepEqual(m.Roots, first.Roots) {
+			return nil, fmt.Errorf("cannot merge multi-root manifests with different roots")
+		}
+	}
+
+	root, prefixes, err := rebase(ms)
+	if err != nil {
+		return nil, err
+	}
+
+	out := emptyLike(first)
+	out.Root = root
+
+	seen := make(map[string]bool)
+	for i, m := range ms {
+		if m.Generated.After(out.Generated) {
+			out.Generated = m.Generated
+		}
+		// Path rules are relative to the scanned root, so recorded
+		// filters only survive when every input shares it.
+		if prefixes[i] != "." || !reflect.DeepEqual(m.Filters, out.Filters) {
+			out.Filters = nil
+		}
+		if !reflect.DeepEqual(m.Archives, out.Archives) {
+			out.Archives = nil
+		}
+
+		for _, n := range m.Nodes {
+			c := copyNode(n)
+			c.Path = filepath.Join(prefixes[i], n.Path)
+			if seen[c.Path] {
+				return nil, fmt.Errorf("overlapping path %q", c.Path)
+			}
+			seen[c.Path] = true
+			out.Nodes = append(out.Nodes, c)
+		}
+		for _, s := range m.Skipped {
+			s.Path = filepath.Join(prefixes[i], s.Path)
+			out.Skipped = append(out.Skipped, s)
+		}
+	}
+
+	synthesizeParents(out, seen)
+
+	sort.Slice(out.Nodes, func(i, j int) bool { return lessPath(out.Nodes[i].Path, out.Nodes[j].Path) })
+	sort.Slice(out.Skipped, func(i, j int) bool { return out.Skipped[i].Path < out.Skipped[j].Path })
+
+	if opts, ok := rollupOptions(ms...); ok {
+		if err := out.BuildRollups(opts); err != nil {
+			return nil, fmt.Errorf("rollups: %w", err)
+		}
+	}
+	return out, nil
+}
+
+// rebase returns the merged root and, per input, the path its nodes move
+// under. Inputs sharing a root are not moved.
+func rebase(ms []*manifest.Manifest) (string, []string, error) {
+	prefixes := make([]string, len(ms))
+	root := ms[0].Root
+
+	same := true
+	for _, m := range ms {
+		same = same && m.Root == root
+	}
+	if same {
+		for i := range prefixes {
+			prefixes[i] = "."
+		}
+		return root, prefixes, nil
+	}
+
+	if len(ms[0].Roots) > 0 {
+		return "", nil, fmt.Errorf("cannot rebase multi-root manifests")
+	}
+
+	for _, m := range ms[1:] {
+		root = commonDir(root, m.Root)
+	}
+	for i, m := range ms {
+		rel, err := filepath.Rel(root, m.Root)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return "", nil, fmt.Errorf("cannot relate root %q to %q", m.Root, root)
+		}
+		prefixes[i] = rel
+	}
+	return root, prefixes, nil
+}
+
+// commonDir returns the deepest directory containing both a and b.
+func commonDir(a, b string) string {
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	for {
+		if rel, err := filepath.Rel(a, b); err == nil && !strings.HasPrefix(rel, "..") {
+			return a
+		}
+		parent := filepath.Dir(a)
+		if parent == a {
+			return a
+		}
+		a = parent
+	}
+}
+
+// synthesizeParents adds bare directory nodes for ancestors that no input
+// recorded, up to ".".
+func synthesizeParents(m *manifest.Manifest, seen map[string]bool) {
+	parentOf := m.ParentFunc()
+	for _, n := range m.Nodes {
+		for p := n.Path; p != "."; {
+			p = parentOf(p)
+			if seen[p] {
+				break
+			}
+			seen[p] = true
+			m.Nodes = append(m.Nodes, &manifest.Node{Path: p, IsDir: true})
+		}
+	}
+}
+
+// rollupOptions infers the options the inputs' rollups were built with.
+// Capabilities do not record dir counts or percentiles, so those are
+// detected from the data.
+func rollupOptions(ms ...*manifest.Manifest) (manifest.RollupOptions, bool) {
+	var (
+		opts manifest.RollupOptions
+		has  bool
+	)
+	for _, m := range ms {
+		caps := m.Manifest.Capabilities.Rollup
+		opts.EnableSizeBytes = opts.EnableSizeBytes || caps.SizeStats
+		opts.EnableFileTypes = opts.EnableFileTypes || caps.FileTypes
+		opts.EnableDepthStats = opts.EnableDepthStats || caps.DepthMetrics
+
+		for _, n := range m.Nodes {
+			if n.Rollup == nil {
+				continue
+			}
+			has = true
+			opts.EnableDirCounts = opts.EnableDirCounts || n.Rollup.TotalDescendantDirs > 0
+			opts.EnablePercentiles = opts.EnablePercentiles || n.Rollup.Size.Percentiles != nil
+		}
+	}
+	return opts, has
+}
+
+// emptyLike returns a manifest with m's metadata and no nodes.
+func emptyLike(m *manifest.Manifest) *manifest.Manifest {
+	return &manifest.Manifest{
+		Manifest:  m.Manifest,
+		Root:      m.Root,
+		Roots:     m.Roots,
+		Generated: m.Generated,
+		Filters:   m.Filters,
+		Archives:  m.Archives,
+	}
+}
+
+// copyNode copies n without its rollup, which is rebuilt for the new tree.
+func copyNode(n *manifest.Node) *manifest.Node {
+	c := *n
+	c.Rollup = nil
+	return &c
+}
+
+func components(path string) int {
+	if path == "." {
+		return 0
+	}
+	return strings.Count(path, string(os.PathSeparator)) + 1
+}
+
+// lessPath orders paths the way a directory walk visits them: parents
+// first, siblings by name.
+func lessPath(a, b string) bool {
+	if a == "." || b == "." {
+		return a == "." && b != "."
+	}
+	as := strings.Split(a, string(filepath.Separator))
+	bs := strings.Split(b, string(filepath.Separator))
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return as[i] < bs[i]
+		}
+	}
+	return len(as) < len(bs)
+}
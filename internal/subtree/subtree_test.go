@@ -0,0 +1,178 @@
+package subtree_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+	"github.com/dtnitsch/manifestor/internal/scanner"
+	"github.com/dtnitsch/manifestor/internal/subtree"
+)
+
+var rollups = manifest.RollupOptions{EnableDirCounts: true, EnableSizeBytes: true, EnablePercentiles: true}
+
+func scan(t *testing.T, root string, fsys fstest.MapFS) *manifest.Manifest {
+	t.Helper()
+
+	m, err := scanner.New(scanner.Options{Root: root, FS: fsys}, scanner.FilterSet{}).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	m.Manifest = manifest.DefaultManifestMeta()
+	if err := m.BuildRollups(rollups); err != nil {
+		t.Fatalf("rollups: %v", err)
+	}
+	return m
+}
+
+func monorepo() fstest.MapFS {
+	return fstest.MapFS{
+		"README.md":                  {Data: []byte("readme")},
+		"services/notes.txt":         {Data: []byte("n")},
+		"services/api/main.go":       {Data: []byte("package main")},
+		"services/api/handlers/h.go": {Data: []byte("package handlers")},
+		"services/web/index.html":    {Data: []byte("<html></html>")},
+		"libs/log/log.go":            {Data: []byte("package log")},
+	}
+}
+
+func paths(m *manifest.Manifest) []string {
+	out := make([]string, len(m.Nodes))
+	for i, n := range m.Nodes {
+		out[i] = n.Path
+	}
+	return out
+}
+
+func TestSplitAndMergeRoundTrip(t *testing.T) {
+	m := scan(t, "repo", monorepo())
+
+	parts, err := subtree.Split(m, 2)
+	if err != nil {
+		t.Fatalf("split: %v", err)
+	}
+
+	var dirs []string
+	for _, p := range parts {
+		dirs = append(dirs, p.Dir)
+		if _, err := p.Manifest.Validate(manifest.ValidateOptions{Strict: true}); err != nil {
+			t.Errorf("part %s invalid: %v", p.Dir, err)
+		}
+		if p.Manifest.Manifest.Version != m.Manifest.Version || p.Manifest.Root != "repo" {
+			t.Errorf("part %s meta = %+v root %q", p.Dir, p.Manifest.Manifest, p.Manifest.Root)
+		}
+	}
+	want := []string{".", filepath.Join("libs", "log"), filepath.Join("services", "api"), filepath.Join("services", "web")}
+	if !reflect.DeepEqual(dirs, want) {
+		t.Fatalf("parts = %v, want %v", dirs, want)
+	}
+
+	// Files at the split depth stay with the top part.
+	top := paths(parts[0].Manifest)
+	if !reflect.DeepEqual(top, []string{".", "README.md", "libs", "services", filepath.Join("services", "notes.txt")}) {
+		t.Errorf("top part = %v", top)
+	}
+
+	ms := make([]*manifest.Manifest, len(parts))
+	for i, p := range parts {
+		ms[i] = p.Manifest
+	}
+	merged, err := subtree.Merge(ms...)
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+
+	if !reflect.DeepEqual(paths(merged), paths(m)) {
+		t.Fatalf("merged paths = %v, want %v", paths(merged), paths(m))
+	}
+	for i, n := range merged.Nodes {
+		if !reflect.DeepEqual(n, m.Nodes[i]) {
+			t.Errorf("node %s = %+v, want %+v", n.Path, n, m.Nodes[i])
+		}
+	}
+}
+
+func TestSplitKeepsArchiveMembersWithArchive(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("lib/x.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := fstest.MapFS{
+		"README.md": {Data: []byte("readme")},
+		"app.zip":   {Data: buf.Bytes()},
+	}
+	m, err := scanner.New(scanner.Options{Root: "repo", FS: fsys, ExpandArchives: true}, scanner.FilterSet{}).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	m.Manifest = manifest.DefaultManifestMeta()
+	if err := m.BuildRollups(rollups); err != nil {
+		t.Fatalf("rollups: %v", err)
+	}
+
+	parts, err := subtree.Split(m, 1)
+	if err != nil {
+		t.Fatalf("split: %v", err)
+	}
+	if len(parts) != 2 || parts[1].Dir != "app.zip" {
+		t.Fatalf("parts = %+v", parts)
+	}
+
+	member := filepath.Join("app.zip"+manifest.ArchiveSep, "lib")
+	want := []string{"app.zip", member, filepath.Join(member, "x.txt")}
+	if got := paths(parts[1].Manifest); !reflect.DeepEqual(got, want) {
+		t.Errorf("archive part = %v, want %v", got, want)
+	}
+	if got := paths(parts[0].Manifest); !reflect.DeepEqual(got, []string{".", "README.md"}) {
+		t.Errorf("top part = %v", got)
+	}
+	for _, p := range parts {
+		if _, err := p.Manifest.Validate(manifest.ValidateOptions{Strict: true}); err != nil {
+			t.Errorf("part %s invalid: %v", p.Dir, err)
+		}
+	}
+}
+
+func TestMergeRejectsOverlap(t *testing.T) {
+	a := scan(t, "repo", fstest.MapFS{"x/a.go": {}})
+	b := scan(t, "repo", fstest.MapFS{"y/b.go": {}})
+
+	if _, err := subtree.Merge(a, b); err == nil {
+		t.Fatal("expected overlapping \".\" to be rejected")
+	}
+}
+
+func TestMergeSiblingRoots(t *testing.T) {
+	api := scan(t, "/repo/services/api", fstest.MapFS{"main.go": {Data: []byte("x")}})
+	web := scan(t, "/repo/services/web", fstest.MapFS{"index.html": {Data: []byte("y")}})
+
+	merged, err := subtree.Merge(api, web)
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if merged.Root != "/repo/services" {
+		t.Errorf("root = %q", merged.Root)
+	}
+
+	want := []string{".", "api", filepath.Join("api", "main.go"), "web", filepath.Join("web", "index.html")}
+	if !reflect.DeepEqual(paths(merged), want) {
+		t.Fatalf("paths = %v, want %v", paths(merged), want)
+	}
+	if r := merged.Nodes[0].Rollup; r == nil || r.TotalDescendantDirs < 2 {
+		t.Errorf("parent rollup not rebuilt: %+v", r)
+	}
+}
@@ -0,0 +1,307 @@
+package verify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dtnitsch/manifestor/internal/archive"
+	"github.com/dtnitsch/manifestor/internal/manifest"
+	"github.com/dtnitsch/manifestor/internal/scanner"
+)
+
+type DriftKind string
+
+const (
+	Missing      DriftKind = "missing"       // in manifest, not on disk
+	Added        DriftKind = "added"         // on disk, not in manifest
+	TypeChanged  DriftKind = "type_changed"  // file <-> directory
+	Resized      DriftKind = "resized"
+	MtimeChanged DriftKind = "mtime_changed"
+	HashMismatch DriftKind = "hash_mismatch"
+)
+
+type Drift struct {
+	Path string    `json:"path" yaml:"path"`
+	Kind DriftKind `json:"kind" yaml:"kind"`
+	Old  string    `json:"old,omitempty" yaml:"old,omitempty"`
+	New  string    `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+type Report struct {
+	Root    string  `json:"root" yaml:"root"`
+	Checked int     `json:"checked" yaml:"checked"`
+	Drift   []Drift `json:"drift" yaml:"drift"`
+}
+
+func (r *Report) HasDrift() bool {
+	return len(r.Drift) > 0
+}
+
+// Counts returns the number of drift entries per kind.
+func (r *Report) Counts() map[DriftKind]int {
+	counts := make(map[DriftKind]int)
+	for _, d := range r.Drift {
+		counts[d.Kind]++
+	}
+	return counts
+}
+
+// Verify rescans root with the filters (and archive expansion) recorded in
+// m and compares every node against the live filesystem. Multi-root
+// manifests are rescanned from their recorded root paths and root is only
+// used as a label. File mtimes are only compared when the
+// manifest recorded them; hashes only when a node carries one.
+func Verify(ctx context.Context, m *manifest.Manifest, root string) (*Report, error) {
+	live, err := rescan(ctx, m, root)
+	if err != nil {
+		return nil, err
+	}
+
+	liveByPath := make(map[string]*manifest.Node, len(live.Nodes))
+	for _, n := range live.Nodes {
+		liveByPath[n.Path] = n
+	}
+
+	archives := make(map[string]bool)
+	for _, n := range m.Nodes {
+		if n.Archive != "" {
+			archives[n.Path] = true
+		}
+	}
+
+	r := &Report{Root: root, Checked: len(m.Nodes)}
+	recorded := make(map[string]bool, len(m.Nodes))
+
+	for _, want := range m.Nodes {
+		recorded[want.Path] = true
+
+		got, ok := liveByPath[want.Path]
+		if !ok {
+			r.Drift = append(r.Drift, Drift{Path: want.Path, Kind: Missing})
+			continue
+		}
+
+		// Archive members are read through the outermost archive file.
+		file, members := splitArchivePath(archives, want.Path)
+		hostPath := filepath.Join(root, file)
+		if rm, rel, ok := m.RootOf(file); ok {
+			hostPath = filepath.Join(rm.Path, rel)
+		}
+
+		if err := compareNode(r, hostPath, members, want, got); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, n := range live.Nodes {
+		// Subtrees collapsed to fit a token budget were never recorded.
+		if _, ok := m.CollapsedUnder(n.Path); ok {
+			continue
+		}
+		if !recorded[n.Path] {
+			r.Drift = append(r.Drift, Drift{Path: n.Path, Kind: Added, New: describe(n)})
+		}
+	}
+
+	sort.SliceStable(r.Drift, func(i, j int) bool {
+		return r.Drift[i].Path < r.Drift[j].Path
+	})
+
+	return r, nil
+}
+
+func rescan(ctx context.Context, m *manifest.Manifest, root string) (*manifest.Manifest, error) {
+	f := m.ScanFilters()
+	filters := scanner.FilterSet{Block: f.Block, Allow: f.Allow}
+
+	opts := scanner.Options{
+		Root:              root,
+		CollectTimestamps: true,
+	}
+	if m.Archives != nil {
+		opts.ExpandArchives = true
+		opts.MaxArchiveDepth = m.Archives.MaxDepth
+	}
+
+	if len(m.Roots) == 0 {
+		live, err := scanner.New(opts, filters).Scan(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("rescan %s: %w", root, err)
+		}
+		return live, nil
+	}
+
+	roots := make([]scanner.Root, 0, len(m.Roots))
+	for _, r := range m.Roots {
+		sr := scanner.Root{Name: r.Name, Path: r.Path}
+		if r.Filters != nil {
+			sr.Filters = scanner.FilterSet{Block: r.Filters.Block, Allow: r.Filters.Allow}
+		}
+		roots = append(roots, sr)
+	}
+
+	live, err := scanner.ScanRoots(ctx, opts, filters, roots)
+	if err != nil {
+		return nil, fmt.Errorf("rescan: %w", err)
+	}
+	return live, nil
+}
+
+// splitArchivePath splits a node path at the recorded archives it passes
+// through: "a.zip!/b.tar!/c" gives "a.zip" and the members ["b.tar", "c"].
+// Paths outside archives come back whole, with no members. When the root
+// itself is an archive, the file is ".".
+func splitArchivePath(archives map[string]bool, path string) (string, []string) {
+	if path == "." {
+		return path, nil
+	}
+	sep := manifest.ArchiveSep + string(filepath.Separator)
+
+	file, start := "", 0
+	if archives["."] {
+		file = "."
+	}
+	var members []string
+	for i := 0; ; {
+		j := strings.Index(path[i:], sep)
+		if j < 0 {
+			break
+		}
+		if cut := i + j; archives[path[:cut]] {
+			if file == "" {
+				file = path[:cut]
+			} else {
+				members = append(members, path[start:cut])
+			}
+			start = cut + len(sep)
+		}
+		i += j + len(sep)
+	}
+
+	if file == "" {
+		return path, nil
+	}
+	return file, append(members, path[start:])
+}
+
+func compareNode(r *Report, hostPath string, members []string, want, got *manifest.Node) error {
+	if want.IsDir != got.IsDir {
+		r.Drift = append(r.Drift, Drift{
+			Path: want.Path,
+			Kind: TypeChanged,
+			Old:  describe(want),
+			New:  describe(got),
+		})
+		return nil
+	}
+
+	if !want.IsDir && want.SizeBytes != got.SizeBytes {
+		r.Drift = append(r.Drift, Drift{
+			Path: want.Path,
+			Kind: Resized,
+			Old:  fmt.Sprint(want.SizeBytes),
+			New:  fmt.Sprint(got.SizeBytes),
+		})
+	}
+
+	// Directory mtimes only move when entries are added or removed, which
+	// is already reported per entry.
+	if !want.IsDir && want.MtimeUnix != 0 && want.MtimeUnix != got.MtimeUnix {
+		r.Drift = append(r.Drift, Drift{
+			Path: want.Path,
+			Kind: MtimeChanged,
+			Old:  fmt.Sprint(want.MtimeUnix),
+			New:  fmt.Sprint(got.MtimeUnix),
+		})
+	}
+
+	if want.Hash != "" && !want.IsDir {
+		algo, _, _ := strings.Cut(want.Hash, ":")
+		if algo != "sha256" {
+			return fmt.Errorf("%s: unsupported hash algorithm %q", want.Path, algo)
+		}
+
+		hash := HashFile
+		if len(members) > 0 {
+			hash = func(path string) (string, error) { return hashMember(path, members) }
+		}
+		sum, err := hash(hostPath)
+		if err != nil {
+			return err
+		}
+		if sum != want.Hash {
+			r.Drift = append(r.Drift, Drift{Path: want.Path, Kind: HashMismatch, Old: want.Hash, New: sum})
+		}
+	}
+
+	return nil
+}
+
+// HashFile returns the content hash of a file in the manifest's
+// "sha256:<hex>" form.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("hash %s: %w", path, err)
+	}
+	defer f.Close()
+
+	sum, err := hashReader(f)
+	if err != nil {
+		return "", fmt.Errorf("hash %s: %w", path, err)
+	}
+	return sum, nil
+}
+
+// hashMember hashes a member of the archive file at path, opening each
+// nested archive in members on the way down.
+func hashMember(path string, members []string) (string, error) {
+	label := strings.Join(append([]string{path}, members...), manifest.ArchiveSep+string(filepath.Separator))
+
+	var fsys fs.FS = os.DirFS(filepath.Dir(path))
+	name := filepath.Base(path)
+	for _, member := range members {
+		member = filepath.ToSlash(member)
+		afs, err := archive.Open(fsys, name, func(m string) bool { return m == member })
+		if err != nil {
+			return "", fmt.Errorf("hash %s: %w", label, err)
+		}
+		defer afs.Close()
+		fsys, name = afs, member
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", fmt.Errorf("hash %s: %w", label, err)
+	}
+	defer f.Close()
+
+	sum, err := hashReader(f)
+	if err != nil {
+		return "", fmt.Errorf("hash %s: %w", label, err)
+	}
+	return sum, nil
+}
+
+func hashReader(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func describe(n *manifest.Node) string {
+	if n.IsDir {
+		return "dir"
+	}
+	return fmt.Sprintf("file %d bytes", n.SizeBytes)
+}
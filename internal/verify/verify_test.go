@@ -0,0 +1,281 @@
+package verify_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dtnitsch/manifestor/internal/filter"
+	"github.com/dtnitsch/manifestor/internal/manifest"
+	"github.com/dtnitsch/manifestor/internal/scanner"
+	"github.com/dtnitsch/manifestor/internal/verify"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyDetectsDrift(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "keep.txt"), "same")
+	writeFile(t, filepath.Join(root, "grow.txt"), "small")
+	writeFile(t, filepath.Join(root, "gone.txt"), "bye")
+	writeFile(t, filepath.Join(root, "hashed.txt"), "original")
+	writeFile(t, filepath.Join(root, ".git", "HEAD"), "ref")
+
+	sc := scanner.New(scanner.Options{Root: root, CollectTimestamps: true}, scanner.FilterSet{
+		Block: []filter.Rule{{Type: filter.Basename, Pattern: ".git"}},
+	})
+	m, err := sc.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, n := range m.Nodes {
+		if n.Path == "hashed.txt" {
+			if n.Hash, err = verify.HashFile(filepath.Join(root, n.Path)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	report, err := verify.Verify(context.Background(), m, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.HasDrift() {
+		t.Fatalf("expected no drift, got %+v", report.Drift)
+	}
+
+	writeFile(t, filepath.Join(root, "grow.txt"), "much larger now")
+	writeFile(t, filepath.Join(root, "hashed.txt"), "modified") // same size
+	writeFile(t, filepath.Join(root, "new.txt"), "hello")
+	writeFile(t, filepath.Join(root, ".git", "ORIG_HEAD"), "ref") // filtered out
+	if err := os.Remove(filepath.Join(root, "gone.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = verify.Verify(context.Background(), m, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]verify.DriftKind{}
+	for _, d := range report.Drift {
+		if d.Kind == verify.MtimeChanged {
+			continue // timing dependent
+		}
+		got[d.Path] = d.Kind
+	}
+
+	want := map[string]verify.DriftKind{
+		"grow.txt":   verify.Resized,
+		"gone.txt":   verify.Missing,
+		"new.txt":    verify.Added,
+		"hashed.txt": verify.HashMismatch,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for p, k := range want {
+		if got[p] != k {
+			t.Fatalf("%s: expected %s, got %s", p, k, got[p])
+		}
+	}
+}
+
+func TestScanFiltersFallsBackToSkippedRules(t *testing.T) {
+	m := &manifest.Manifest{
+		Skipped: []manifest.SkippedEntry{
+			{Path: ".git", IsDir: true, Rule: "basename:.git"},
+			{Path: "var/log", IsDir: true, Rule: "path:var/log"},
+		},
+	}
+
+	f := m.ScanFilters()
+	if len(f.Block) != 2 || f.Block[1].Type != filter.Path || f.Block[1].Pattern != "var/log" {
+		t.Fatalf("unexpected filters: %+v", f)
+	}
+}
+
+func TestVerifyMultiRoot(t *testing.T) {
+	a, b := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(a, "x.txt"), "x")
+	writeFile(t, filepath.Join(b, "y.txt"), "y")
+
+	m, err := scanner.ScanRoots(context.Background(), scanner.Options{CollectTimestamps: true}, scanner.FilterSet{}, []scanner.Root{
+		{Name: "a", Path: a},
+		{Name: "b", Path: b},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, n := range m.Nodes {
+		if n.Path == filepath.Join("b", "y.txt") {
+			if n.Hash, err = verify.HashFile(filepath.Join(b, "y.txt")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	report, err := verify.Verify(context.Background(), m, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.HasDrift() {
+		t.Fatalf("expected no drift, got %+v", report.Drift)
+	}
+
+	writeFile(t, filepath.Join(b, "z.txt"), "z")
+
+	report, err = verify.Verify(context.Background(), m, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Drift) != 1 || report.Drift[0].Path != filepath.Join("b", "z.txt") || report.Drift[0].Kind != verify.Added {
+		t.Fatalf("drift = %+v", report.Drift)
+	}
+}
+
+func tarGzOf(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, data := range files {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: time.Unix(1600000000, 0)}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zipOf(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func sha256Of(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestVerifyHashedArchiveMembers(t *testing.T) {
+	root := t.TempDir()
+	release := func(app string) string {
+		inner := zipOf(t, map[string][]byte{"x.txt": []byte("x")})
+		return string(tarGzOf(t, map[string][]byte{"bin/app": []byte(app), "inner.jar": inner}))
+	}
+	writeFile(t, filepath.Join(root, "dist", "rel.tar.gz"), release("binary"))
+
+	m, err := scanner.New(scanner.Options{Root: root, CollectTimestamps: true, ExpandArchives: true}, scanner.FilterSet{}).Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := filepath.Join("dist", "rel.tar.gz!", "bin", "app")
+	nested := filepath.Join("dist", "rel.tar.gz!", "inner.jar!", "x.txt")
+	hashed := 0
+	for _, n := range m.Nodes {
+		switch n.Path {
+		case app:
+			n.Hash = sha256Of("binary")
+			hashed++
+		case nested:
+			n.Hash = sha256Of("x")
+			hashed++
+		}
+	}
+	if hashed != 2 {
+		t.Fatalf("hashed %d members, want 2: %v", hashed, m.Nodes)
+	}
+
+	report, err := verify.Verify(context.Background(), m, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.HasDrift() {
+		t.Fatalf("expected no drift, got %+v", report.Drift)
+	}
+
+	// Same size and header mtime; only the content differs.
+	writeFile(t, filepath.Join(root, "dist", "rel.tar.gz"), release("BINARY"))
+
+	report, err = verify.Verify(context.Background(), m, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hashDrift []string
+	for _, d := range report.Drift {
+		if d.Kind == verify.HashMismatch {
+			hashDrift = append(hashDrift, d.Path)
+		}
+	}
+	if len(hashDrift) != 1 || hashDrift[0] != app {
+		t.Fatalf("hash drift = %v, want only %s (all drift: %+v)", hashDrift, app, report.Drift)
+	}
+}
+
+func TestVerifyHashedMemberOfArchiveRoot(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "app.zip")
+	writeFile(t, root, string(zipOf(t, map[string][]byte{"a/b.txt": []byte("b")})))
+
+	m, err := scanner.New(scanner.Options{Root: root, ExpandArchives: true}, scanner.FilterSet{}).Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range m.Nodes {
+		if n.Path == filepath.Join("a", "b.txt") {
+			n.Hash = sha256Of("b")
+		}
+	}
+
+	report, err := verify.Verify(context.Background(), m, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.HasDrift() {
+		t.Fatalf("expected no drift, got %+v", report.Drift)
+	}
+}
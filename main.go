@@ -1,22 +1,32 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 
+	"github.com/dtnitsch/manifestor/internal/compress"
 	"github.com/dtnitsch/manifestor/internal/config"
 	"github.com/dtnitsch/manifestor/internal/manifest"
 	"github.com/dtnitsch/manifestor/internal/output"
-	"github.com/dtnitsch/manifestor/internal/scanner"
+	"github.com/dtnitsch/manifestor/pkg/manifestor"
 	"github.com/urfave/cli/v2"
 )
 
 const version = "0.3.0"
 
 func main() {
-	app := &cli.App{
+	if err := newApp().Run(os.Args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func newApp() *cli.App {
+	return &cli.App{
 		Name:    "manifestor",
 		Usage:   "Generate LLM-friendly filesystem manifests",
 		Version: version,
@@ -29,12 +39,48 @@ func main() {
 			&cli.StringFlag{
 				Name:    "format",
 				Aliases: []string{"f"},
-				Usage:   "Output format: json or yaml (overrides config)",
+				Usage:   "Output format: json, yaml, ndjson, markdown, tree, csv, tsv, sqlite or html (overrides config)",
 			},
 			&cli.StringFlag{
 				Name:    "output",
 				Aliases: []string{"o"},
-				Usage:   "Output file path (overrides config)",
+				Usage:   "Output file path, or - for stdout (overrides config)",
+			},
+			&cli.StringFlag{
+				Name:  "compress",
+				Usage: "Compress the output: gzip, zstd or none (default: by extension, e.g. .gz, .zst; overrides config)",
+			},
+			&cli.StringFlag{
+				Name:  "layout",
+				Usage: "Document layout: flat, tree or compact (overrides config)",
+			},
+			&cli.IntFlag{
+				Name:  "max-depth",
+				Usage: "markdown/tree: expand at most this many levels (overrides config)",
+			},
+			&cli.IntFlag{
+				Name:  "collapse-files",
+				Usage: "markdown/tree: summarize directories with fewer files (overrides config)",
+			},
+			&cli.Int64Flag{
+				Name:  "collapse-bytes",
+				Usage: "markdown/tree: summarize directories smaller than this (overrides config)",
+			},
+			&cli.IntFlag{
+				Name:  "token-budget",
+				Usage: "Collapse subtrees until the output fits about N tokens (overrides config)",
+			},
+			&cli.StringFlag{
+				Name:  "rollups-file",
+				Usage: "csv/tsv: also write directory rollups to this file (overrides config)",
+			},
+			&cli.BoolFlag{
+				Name:  "sqlite-history",
+				Usage: "sqlite: keep the database and append this scan to its history table (overrides config)",
+			},
+			&cli.BoolFlag{
+				Name:  "llm",
+				Usage: "Also write manifestor.llm.yaml for agents (overrides config)",
 			},
 			&cli.StringFlag{
 				Name:  "config",
@@ -42,10 +88,20 @@ func main() {
 				Value: "manifestor-config.yaml",
 			},
 		},
+		Commands: []*cli.Command{
+			validateCommand(),
+			verifyCommand(),
+			diffCommand(),
+			splitCommand(),
+			mergeCommand(),
+			llmCommand(),
+			schemaCommand(),
+		},
 		Action: func(c *cli.Context) error {
-			logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-				Level: slog.LevelInfo,
-			}))
+			logger := newLogger(os.Stdout)
+			if c.String("output") == output.Stdout {
+				logger = newLogger(os.Stderr)
+			}
 
 			// Load config
 			configPath := c.String("config")
@@ -57,6 +113,7 @@ func main() {
 			// Override config with CLI flags
 			if c.IsSet("root") {
 				cfg.Scanner.Root = c.String("root")
+				cfg.Scanner.Roots = nil
 			}
 			if c.IsSet("format") {
 				cfg.Output.Format = c.String("format")
@@ -64,6 +121,38 @@ func main() {
 			if c.IsSet("output") {
 				cfg.Output.File = c.String("output")
 			}
+			if c.IsSet("compress") {
+				cfg.Output.Compress = c.String("compress")
+			}
+			if c.IsSet("layout") {
+				cfg.Output.Layout = c.String("layout")
+			}
+			if c.IsSet("max-depth") {
+				cfg.Output.Render.MaxDepth = c.Int("max-depth")
+			}
+			if c.IsSet("collapse-files") {
+				cfg.Output.Render.CollapseBelowFiles = c.Int("collapse-files")
+			}
+			if c.IsSet("collapse-bytes") {
+				cfg.Output.Render.CollapseBelowBytes = c.Int64("collapse-bytes")
+			}
+			if c.IsSet("token-budget") {
+				cfg.Output.TokenBudget = c.Int("token-budget")
+			}
+			if c.IsSet("rollups-file") {
+				cfg.Output.CSV.RollupsFile = c.String("rollups-file")
+			}
+			if c.IsSet("sqlite-history") {
+				cfg.Output.SQLite.History = c.Bool("sqlite-history")
+			}
+			if c.IsSet("llm") {
+				cfg.Output.LLM.Enable = c.Bool("llm")
+			}
+
+			// Keep stdout for the manifest.
+			if cfg.Output.File == output.Stdout {
+				logger = newLogger(os.Stderr)
+			}
 
 			if err := run(logger, cfg); err != nil {
 				return err
@@ -73,90 +162,351 @@ func main() {
 			return nil
 		},
 	}
+}
 
-	if err := app.Run(os.Args); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
+func newLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
 }
 
 func run(logger *slog.Logger, cfg *config.Config) error {
-    filters := scanner.FilterSet{
-        Block: cfg.Filters.Block,
-        Allow: cfg.Filters.Allow,
-    }
-
-	opts := scanner.Options{
-        Root:              cfg.Scanner.Root,
-        MaxWorkers:        cfg.Scanner.MaxWorkers,
-        FollowSymlinks:    false,
-        CollectInodes:     true,
-        CollectTimestamps: true,
-        CollectFileCounts: true,
-	}
-
-    sc := scanner.New(opts, filters)
-
-    m, err := sc.Scan(context.Background())
-    if err != nil {
-        return err
-    }
-
-	// Double check skipped things
-	skippedCount := len(m.Skipped)
-	if skippedCount > 0 {
+	opts := []manifestor.ScanOption{
+		manifestor.WithBlock(cfg.Filters.Block...),
+		manifestor.WithAllow(cfg.Filters.Allow...),
+		manifestor.WithMaxWorkers(cfg.Scanner.MaxWorkers),
+	}
+	if cfg.Scanner.Archives.Enable {
+		opts = append(opts, manifestor.WithArchives(cfg.Scanner.Archives.MaxDepth))
+	}
+	if cfg.Rollup.Enable {
+		opts = append(opts, manifestor.WithRollups(rollupOptions(cfg.Rollup)...))
+	}
+
+	spec := outputSpec{
+		format: cfg.Output.Format,
+		layout: cfg.Output.Layout,
+		render: output.RenderOptions{
+			MaxDepth:      cfg.Output.Render.MaxDepth,
+			CollapseFiles: cfg.Output.Render.CollapseBelowFiles,
+			CollapseBytes: cfg.Output.Render.CollapseBelowBytes,
+		},
+		csv: output.CSVOptions{
+			Columns:       cfg.Output.CSV.Columns,
+			RollupColumns: cfg.Output.CSV.RollupColumns,
+			Headers:       cfg.Output.CSV.Headers,
+		},
+		rollupsFile: cfg.Output.CSV.RollupsFile,
+		sqlite:      output.SQLiteOptions{History: cfg.Output.SQLite.History},
+		compress:    cfg.Output.Compress,
+	}
+	if err := checkLayout(spec.format, spec.layout); err != nil {
+		return err
+	}
+	if err := spec.checkFile(cfg.Output.File); err != nil {
+		return err
+	}
+	if err := output.CheckCSVColumns(spec.csv); err != nil {
+		return err
+	}
+	if cfg.Output.TokenBudget > 0 && (spec.format == "ndjson" || spec.format == "sqlite") {
+		return fmt.Errorf("a token budget is not available for %s output", spec.format)
+	}
+	// The protocol's yq examples query a flat nodes list.
+	if cfg.Output.LLM.Enable && spec.format != "json" && spec.format != "yaml" {
+		return fmt.Errorf("the llm protocol file is not available for %s output", spec.format)
+	}
+	if cfg.Output.LLM.Enable && spec.layout != "" && spec.layout != "flat" {
+		return fmt.Errorf("the llm protocol file is not available for the %s layout", spec.layout)
+	}
+	if cfg.Output.LLM.Enable && cfg.Output.File == output.Stdout {
+		return fmt.Errorf("the llm protocol file needs the manifest in a file, not stdout")
+	}
+
+	// NDJSON is written while scanning, so nodes are never all in memory.
+	streaming := spec.format == "ndjson"
+	var stream io.WriteCloser
+	if streaming {
+		f, err := output.Create(cfg.Output.File, spec.compress)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		stream = f
+		opts = append(opts, manifestor.WithNDJSON(f))
+	}
+
+	var (
+		m   *manifestor.Manifest
+		err error
+	)
+	if len(cfg.Scanner.Roots) > 0 {
+		m, err = manifestor.ScanRoots(context.Background(), scanRoots(cfg.Scanner.Roots), opts...)
+	} else {
+		m, err = manifestor.Scan(context.Background(), cfg.Scanner.Root, opts...)
+	}
+	if err != nil {
+		return err
+	}
+
+	if skippedCount := len(m.Skipped); skippedCount > 0 {
 		logger.Info("skipped directories", "count", skippedCount, "list", m.PrettySkipped())
+	}
 
-		if err := scanner.AssertNoSkippedChildLeakage(m); err != nil {
-			return fmt.Errorf("skipped children: %w", err)
+	if streaming {
+		if cfg.Validate.Enable {
+			logger.Warn("validation skipped for streamed output; run `manifestor validate` on the file", "file", cfg.Output.File)
 		}
+		return stream.Close()
 	}
 
-	if cfg.Rollup.Enable {
-		err := m.BuildRollups(manifest.RollupOptions{
-			EnableDirCounts: cfg.Rollup.EnableDirCounts,
-			EnableSizeBytes: cfg.Rollup.EnableSizeBytes,
-			EnableFileTypes: cfg.Rollup.EnableFileTypes,
-			EnableDepthStats: cfg.Rollup.EnableDepthStats,
-			EnablePercentiles: cfg.Rollup.EnablePercentiles,
-		})
+	// Capability invariants only apply to rollups; policies apply to any node.
+	var validationErr error
+	if cfg.Validate.Enable {
+		vopts, err := validateOptions(cfg)
 		if err != nil {
-			return fmt.Errorf("rollups: %w", err)
+			return err
+		}
+
+		report, err := manifestor.Validate(m, vopts...)
+		if report == nil {
+			return err
+		}
+		spec.html.Validation = report
+
+		for _, v := range report.Violations {
+			manifest.LogViolation(logger, v)
+		}
+		if report.Truncated {
+			logger.Warn("violation limit reached; remaining violations not reported", "limit", cfg.Validate.MaxViolations)
 		}
-		if cfg.Validate.Enable {
-            violations, err := m.Validate(manifest.ValidateOptions{
-                Strict: true,
-            })
-			// Violations are returned for reporting; fatal capability violations surface via err.
-            if err != nil {
-                return fmt.Errorf("validation failed: %w", err)
-            }
 
-            for _, v := range violations {
-                if v.Severity == manifest.SeverityWarning {
-					manifest.LogViolation(logger, v)
-                }
-            }
+		if report.Summary.Total > 0 {
+			manifest.LogViolationSummary(logger, report.Summary)
+		}
 
-			summary := manifest.SummarizeViolations(violations)
-			if summary.HasErrors() {
-				manifest.LogViolationSummary(logger, summary)
-				return fmt.Errorf("validation failed")
+		// Fatal violations surface via err, after everything has been
+		// reported. The html report shows them, so it is still written.
+		if err != nil {
+			validationErr = fmt.Errorf("validation failed: %w", err)
+			if spec.format != "html" {
+				return validationErr
 			}
 		}
 	}
 
-	// Set defaults
-	m.Manifest = manifest.DefaultManifestMeta()
+	if cfg.Output.TokenBudget > 0 {
+		m, err = manifestor.FitTokenBudget(m, manifestor.BudgetOptions{
+			Tokens:    cfg.Output.TokenBudget,
+			Estimator: manifestor.CharsPerToken(cfg.Output.CharsPerToken),
+			Encode: func(m *manifestor.Manifest) ([]byte, error) {
+				var b bytes.Buffer
+				err := spec.encode(&b, m)
+				return b.Bytes(), err
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("token budget: %w", err)
+		}
+		logger.Info("fit token budget", "tokens", m.Budget.Tokens, "estimated", m.Budget.Estimated, "collapsed", len(m.Budget.Collapsed))
+	}
 
 	// Write output based on configured format
-	switch cfg.Output.Format {
+	if err := writeManifest(spec, cfg.Output.File, m); err != nil {
+		return err
+	}
+	if validationErr != nil {
+		logger.Warn("wrote html report with failed validation", "file", cfg.Output.File)
+		return validationErr
+	}
+
+	if cfg.Output.LLM.Enable {
+		err := writeProtocol(cfg.Output.LLM.File, m, spec, protocolConfig{
+			manifestFile:  cfg.Output.File,
+			depth:         cfg.Output.LLM.Depth,
+			charsPerToken: cfg.Output.CharsPerToken,
+		})
+		if err != nil {
+			return err
+		}
+		logger.Info("wrote llm protocol file", "file", cfg.Output.LLM.File)
+	}
+	return nil
+}
+
+// outputSpec is how a manifest is encoded.
+type outputSpec struct {
+	format string
+	layout string
+	render output.RenderOptions
+	csv    output.CSVOptions
+	sqlite output.SQLiteOptions
+	html   output.HTMLOptions
+
+	// csv/tsv: where to write directory rollups, if anywhere
+	rollupsFile string
+
+	// gzip, zstd or none; empty compresses by file extension
+	compress string
+}
+
+func writeManifest(spec outputSpec, path string, m *manifestor.Manifest) error {
+	if err := checkLayout(spec.format, spec.layout); err != nil {
+		return err
+	}
+
+	if err := spec.checkFile(path); err != nil {
+		return err
+	}
+
+	// A database is a file, not a stream.
+	if spec.format == "sqlite" {
+		return output.WriteSQLite(path, m, spec.sqlite)
+	}
+
+	f, err := output.Create(path, spec.compress)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := spec.encode(f, m); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if (spec.format == "csv" || spec.format == "tsv") && spec.rollupsFile != "" {
+		rf, err := output.Create(spec.rollupsFile, spec.compress)
+		if err != nil {
+			return fmt.Errorf("rollups file: %w", err)
+		}
+		defer rf.Close()
+
+		if err := output.EncodeCSVRollups(rf, m, spec.csvOptions()); err != nil {
+			return err
+		}
+		return rf.Close()
+	}
+	return nil
+}
+
+// checkFile rejects destinations the format cannot be written to.
+func (s outputSpec) checkFile(path string) error {
+	if err := compress.Check(s.compress); err != nil {
+		return err
+	}
+	if s.format == "sqlite" {
+		if path == output.Stdout {
+			return fmt.Errorf("sqlite output can only be written to a file")
+		}
+		c := s.compress
+		if c == "" {
+			c = compress.FromPath(path)
+		}
+		if c != compress.None {
+			return fmt.Errorf("sqlite output cannot be compressed")
+		}
+	}
+	if path == output.Stdout && s.rollupsFile == output.Stdout && (s.format == "csv" || s.format == "tsv") {
+		return fmt.Errorf("the manifest and the rollups file cannot both be written to stdout")
+	}
+	return nil
+}
+
+// csvOptions are the csv options with the delimiter of the format.
+func (s outputSpec) csvOptions() output.CSVOptions {
+	opts := s.csv
+	if s.format == "tsv" {
+		opts.Comma = '\t'
+	}
+	return opts
+}
+
+func (s outputSpec) encode(w io.Writer, m *manifestor.Manifest) error {
+	var doc any = m
+	switch s.layout {
+	case "tree":
+		t, err := m.Tree()
+		if err != nil {
+			return fmt.Errorf("tree layout: %w", err)
+		}
+		doc = t
+	case "compact":
+		doc = m.Compact()
+	}
+
+	switch s.format {
 	case "yaml":
-		return output.WriteYAML(cfg.Output.File, m)
+		return output.EncodeYAML(w, doc)
 	case "json":
-		return output.WriteJSON(cfg.Output.File, m)
+		return output.EncodeJSON(w, doc)
+	case "ndjson":
+		return output.EncodeNDJSON(w, m)
+	case "markdown":
+		return output.RenderMarkdown(w, m, s.render)
+	case "tree":
+		return output.RenderTree(w, m, s.render)
+	case "csv", "tsv":
+		return output.EncodeCSV(w, m, s.csvOptions())
+	case "sqlite":
+		return fmt.Errorf("sqlite output can only be written to a file")
+	case "html":
+		return output.EncodeHTML(w, m, s.html)
 	default:
-		return fmt.Errorf("unsupported output format: %s (supported: json, yaml)", cfg.Output.Format)
+		return fmt.Errorf("unsupported output format: %s (supported: json, yaml, ndjson, markdown, tree, csv, tsv, sqlite, html)", s.format)
 	}
 }
 
+// checkLayout rejects unknown layouts, and the tree and compact layouts for
+// formats other than json and yaml.
+func checkLayout(format, layout string) error {
+	switch layout {
+	case "", "flat":
+		return nil
+	case "tree", "compact":
+		if format != "json" && format != "yaml" {
+			return fmt.Errorf("the %s layout is not available for %s output", layout, format)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported layout: %s (supported: flat, tree, compact)", layout)
+	}
+}
+
+
+func scanRoots(roots []config.RootConfig) []manifestor.Root {
+	out := make([]manifestor.Root, 0, len(roots))
+	for _, r := range roots {
+		out = append(out, manifestor.Root{
+			Name: r.Name,
+			Path: r.Path,
+			Filters: manifestor.FilterSet{
+				Block: r.Filters.Block,
+				Allow: r.Filters.Allow,
+			},
+		})
+	}
+	return out
+}
+
+func rollupOptions(rc config.RollupConfig) []manifestor.RollupOption {
+	var opts []manifestor.RollupOption
+	if rc.EnableDirCounts {
+		opts = append(opts, manifestor.WithDirCounts())
+	}
+	if rc.EnableSizeBytes {
+		opts = append(opts, manifestor.WithSizeStats())
+	}
+	if rc.EnableFileTypes {
+		opts = append(opts, manifestor.WithFileTypes())
+	}
+	if rc.EnableDepthStats {
+		opts = append(opts, manifestor.WithDepthStats())
+	}
+	if rc.EnablePercentiles {
+		opts = append(opts, manifestor.WithPercentiles())
+	}
+	return opts
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifyCommand(t *testing.T) {
+	dir := t.TempDir()
+	tree := filepath.Join(dir, "tree")
+	if err := os.MkdirAll(filepath.Join(tree, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tree, "src", "a.go"), []byte("package a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := filepath.Join(dir, "manifestor-config.yaml")
+	if err := os.WriteFile(cfg, []byte("output:\n  format: yaml\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "manifest.yaml")
+
+	if err := newApp().Run([]string{"manifestor", "--config", cfg, "--root", tree, "--output", path}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The invocation documented in the README.
+	if err := newApp().Run([]string{"manifestor", "verify", "--root", tree, path}); err != nil {
+		t.Fatalf("verify --root DIR MANIFEST: %v", err)
+	}
+
+	err := newApp().Run([]string{"manifestor", "verify", path, "--root", tree})
+	if err == nil || !strings.Contains(err.Error(), "flags must come before the manifest path") {
+		t.Errorf("verify MANIFEST --root DIR: err = %v", err)
+	}
+}
+
+func TestLLMNeedsFlatManifest(t *testing.T) {
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "manifestor-config.yaml")
+	if err := os.WriteFile(cfg, []byte("output:\n  format: yaml\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "manifest.out")
+
+	for _, args := range [][]string{
+		{"--layout", "tree"},
+		{"--layout", "compact"},
+		{"--format", "markdown"},
+		{"--format", "csv"},
+		{"--format", "html"},
+	} {
+		args = append([]string{"manifestor", "--config", cfg, "--root", dir, "--output", out, "--llm"}, args...)
+		err := newApp().Run(args)
+		if err == nil || !strings.Contains(err.Error(), "llm protocol file is not available") {
+			t.Errorf("%v: err = %v", args[8:], err)
+		}
+	}
+}
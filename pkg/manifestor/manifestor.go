@@ -0,0 +1,205 @@
+// Package manifestor is the public Go API for scanning directory trees into
+// LLM-friendly manifests, building directory rollups and validating them.
+//
+// The manifestor CLI is a thin client of this package.
+package manifestor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/dtnitsch/manifestor/internal/budget"
+	"github.com/dtnitsch/manifestor/internal/diff"
+	"github.com/dtnitsch/manifestor/internal/input"
+	"github.com/dtnitsch/manifestor/internal/llm"
+	"github.com/dtnitsch/manifestor/internal/manifest"
+	"github.com/dtnitsch/manifestor/internal/output"
+	"github.com/dtnitsch/manifestor/internal/policy"
+	"github.com/dtnitsch/manifestor/internal/scanner"
+	"github.com/dtnitsch/manifestor/internal/subtree"
+	"github.com/dtnitsch/manifestor/internal/verify"
+)
+
+// Scan walks root and returns its manifest, with metadata filled in for the
+// current format version. Inodes and timestamps are collected by default;
+// rollups are only built when WithRollups is given.
+func Scan(ctx context.Context, root string, opts ...ScanOption) (*Manifest, error) {
+	return scan(ctx, root, nil, nil, opts)
+}
+
+// ScanFS is Scan over an arbitrary filesystem such as embed.FS or
+// fstest.MapFS. The manifest root is recorded as ".". Inodes are only
+// reported when fsys implements FactsFS.
+func ScanFS(ctx context.Context, fsys fs.FS, opts ...ScanOption) (*Manifest, error) {
+	return scan(ctx, ".", fsys, nil, opts)
+}
+
+// ScanRoots scans several roots into one manifest. Each root's nodes are
+// namespaced under its name, and the synthetic "." node above them carries
+// the aggregate rollup. Root filters add to those given by WithBlock and
+// WithAllow.
+func ScanRoots(ctx context.Context, roots []Root, opts ...ScanOption) (*Manifest, error) {
+	return scan(ctx, "", nil, roots, opts)
+}
+
+func scan(ctx context.Context, root string, fsys fs.FS, roots []Root, opts []ScanOption) (*Manifest, error) {
+	c := scanConfig{
+		scanner: scanner.Options{
+			Root:              root,
+			FS:                fsys,
+			CollectInodes:     true,
+			CollectTimestamps: true,
+			CollectFileCounts: true,
+		},
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	var stream *ndjsonSink
+	if c.ndjson != nil {
+		stream = &ndjsonSink{w: output.NewNDJSONWriter(c.ndjson)}
+		if c.buildRollups {
+			stream.rollups = manifest.NewRollupBuilder(rollupOptions(c.rollup))
+		}
+		c.scanner.Sink = stream
+	}
+
+	var (
+		m   *Manifest
+		err error
+	)
+	if roots != nil {
+		m, err = scanner.ScanRoots(ctx, c.scanner, c.filters, roots)
+	} else {
+		m, err = scanner.New(c.scanner, c.filters).Scan(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Streamed nodes are gone by now; only the trailer remains to write.
+	if stream != nil {
+		if err := stream.finish(m.Skipped); err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+
+	if err := scanner.AssertNoSkippedChildLeakage(m); err != nil {
+		return nil, fmt.Errorf("skipped children: %w", err)
+	}
+
+	m.Manifest = manifest.DefaultManifestMeta()
+
+	if c.buildRollups {
+		if err := BuildRollups(m, c.rollup...); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// BuildRollups computes directory rollups in place and declares the
+// matching capabilities in the manifest metadata.
+func BuildRollups(m *Manifest, opts ...RollupOption) error {
+	if err := m.BuildRollups(rollupOptions(opts)); err != nil {
+		return fmt.Errorf("rollups: %w", err)
+	}
+	return nil
+}
+
+// Validate checks the manifest's declared capability invariants, node-level
+// rollup consistency and any policies. Validation is strict unless
+// WithLenient is given.
+//
+// The report is returned even when err is non-nil; err is set when at least
+// one error-severity violation was found (or an option was invalid, in
+// which case the report is nil).
+func Validate(m *Manifest, opts ...ValidateOption) (*ValidationReport, error) {
+	c := validateConfig{opts: manifest.ValidateOptions{Strict: true}}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if len(c.policies) > 0 {
+		set, err := policy.Compile(c.policies)
+		if err != nil {
+			return nil, fmt.Errorf("policies: %w", err)
+		}
+		if len(set) > 0 {
+			c.opts.Checkers = append(c.opts.Checkers, set)
+		}
+	}
+
+	return m.Validate(c.opts)
+}
+
+// ParseSeverityOverrides converts invariant name -> "error" | "warning" |
+// "info" | "off" into typed overrides for WithSeverityOverrides.
+func ParseSeverityOverrides(raw map[string]string) (map[string]Severity, error) {
+	return manifest.ParseSeverityOverrides(raw)
+}
+
+// NewBaseline records violations as a baseline for WithBaseline.
+func NewBaseline(violations []InvariantViolation) *Baseline {
+	return manifest.NewBaseline(violations)
+}
+
+// Load reads a JSON, YAML or NDJSON manifest file, optionally gzip or zstd
+// compressed, migrating older format versions to the current model.
+func Load(path string) (*Manifest, error) {
+	return input.Load(path)
+}
+
+// LoadBaseline reads a baseline file written by the CLI.
+func LoadBaseline(path string) (*Baseline, error) {
+	return input.LoadBaseline(path)
+}
+
+// Diff computes the structured changes from before to after.
+func Diff(before, after *Manifest) *ChangeSet {
+	return diff.Compare(before, after)
+}
+
+// Split cuts m into one manifest per directory depth levels below the root,
+// plus a part (Dir ".") for everything above them. Rollups are rebuilt per
+// part.
+func Split(m *Manifest, depth int) ([]SplitPart, error) {
+	return subtree.Split(m, depth)
+}
+
+// Merge combines sibling manifests, such as the parts produced by Split or
+// scans of sibling directories. Overlapping paths are rejected and rollups
+// are rebuilt over the merged tree.
+func Merge(ms ...*Manifest) (*Manifest, error) {
+	return subtree.Merge(ms...)
+}
+
+// Verify compares a manifest against the live filesystem at root, using
+// the filter rules recorded in the manifest.
+func Verify(ctx context.Context, m *Manifest, root string) (*DriftReport, error) {
+	return verify.Verify(ctx, m, root)
+}
+
+// FitTokenBudget returns a copy of m whose encoding, as produced by
+// opts.Encode, fits opts.Tokens by collapsing the deepest, least active
+// directories. Collapsed directories are listed in Budget.Collapsed.
+func FitTokenBudget(m *Manifest, opts BudgetOptions) (*Manifest, error) {
+	return budget.Fit(m, opts)
+}
+
+// GenerateProtocol builds the manifestor.llm.yaml document for m: folder
+// summaries, entry points, per-subtree token costs and lazy-loading
+// instructions. Write it with EncodeProtocol.
+func GenerateProtocol(m *Manifest, opts ProtocolOptions) (*Protocol, error) {
+	return llm.Generate(m, opts)
+}
+
+// EncodeProtocol writes p as YAML.
+func EncodeProtocol(w io.Writer, p *Protocol) error {
+	return llm.Encode(w, p)
+}
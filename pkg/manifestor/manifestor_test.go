@@ -0,0 +1,258 @@
+package manifestor_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/dtnitsch/manifestor/pkg/manifestor"
+	"gopkg.in/yaml.v3"
+)
+
+func TestScanRollupsValidate(t *testing.T) {
+	root := t.TempDir()
+	for path, content := range map[string]string{
+		"main.go":        "package main",
+		"pkg/lib.go":     "package pkg",
+		"pkg/.env":       "SECRET=1",
+		".git/HEAD":      "ref: main",
+		"docs/readme.md": "# docs",
+	} {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := manifestor.Scan(context.Background(), root,
+		manifestor.WithBlock(manifestor.FilterRule{Type: manifestor.Basename, Pattern: ".git"}),
+		manifestor.WithRollups(manifestor.AllRollups()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Manifest.Version != manifestor.CurrentVersion || !m.Manifest.Capabilities.Rollup.SizeStats {
+		t.Fatalf("unexpected metadata: %+v", m.Manifest)
+	}
+	if len(m.Skipped) != 1 || m.Skipped[0].Path != ".git" {
+		t.Fatalf("expected .git to be skipped, got %+v", m.Skipped)
+	}
+
+	report, err := manifestor.Validate(m)
+	if err != nil {
+		t.Fatalf("expected valid manifest: %v", err)
+	}
+	if report.Summary.Total != 0 {
+		t.Fatalf("unexpected violations: %+v", report.Violations)
+	}
+
+	report, err = manifestor.Validate(m, manifestor.WithPolicies(manifestor.PolicySpec{
+		Name: "no-env-files",
+		Deny: `name == ".env"`,
+	}))
+	if err == nil || report.Summary.Errors != 1 || report.Violations[0].Path != "pkg/.env" {
+		t.Fatalf("expected one policy violation, got %+v (err=%v)", report, err)
+	}
+}
+
+func TestValidateRejectsBadPolicy(t *testing.T) {
+	report, err := manifestor.Validate(&manifestor.Manifest{},
+		manifestor.WithPolicies(manifestor.PolicySpec{Name: "broken", Deny: "size_bytes >"}))
+	if err == nil || report != nil {
+		t.Fatalf("expected option error, got report=%v err=%v", report, err)
+	}
+}
+
+func TestScanFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt":     {Data: []byte("aaa")},
+		"sub/b.txt": {Data: []byte("b")},
+	}
+
+	m, err := manifestor.ScanFS(context.Background(), fsys, manifestor.WithRollups(manifestor.AllRollups()))
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if m.Root != "." || len(m.Nodes) != 4 {
+		t.Fatalf("root %q with %d nodes", m.Root, len(m.Nodes))
+	}
+	if _, err := manifestor.Validate(m); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+}
+
+func TestScanNDJSONMatchesScan(t *testing.T) {
+	mtime := time.Unix(1700000000, 0)
+	fsys := fstest.MapFS{
+		"README.md":             {Data: []byte("readme"), ModTime: mtime},
+		"a/x.go":                {Data: []byte("package a"), ModTime: mtime},
+		"a/b/c/deep.txt":        {Data: []byte("deep"), ModTime: mtime.Add(time.Hour)},
+		"a/b/y.go":              {Data: []byte("package b"), ModTime: mtime},
+		"a-sibling/z.txt":       {Data: []byte("z"), ModTime: mtime},
+		"node_modules/pkg/i.js": {Data: []byte("x"), ModTime: mtime},
+	}
+	opts := []manifestor.ScanOption{
+		manifestor.WithBlock(manifestor.FilterRule{Type: manifestor.Basename, Pattern: "node_modules"}),
+		manifestor.WithRollups(manifestor.AllRollups()),
+	}
+
+	want, err := manifestor.ScanFS(context.Background(), fsys, opts...)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "m.ndjson")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	streamed, err := manifestor.ScanFS(context.Background(), fsys, append(opts, manifestor.WithNDJSON(f))...)
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(streamed.Nodes) != 0 || len(streamed.Skipped) != 1 {
+		t.Errorf("streamed manifest holds %d nodes, %d skipped", len(streamed.Nodes), len(streamed.Skipped))
+	}
+
+	got, err := manifestor.Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	if !reflect.DeepEqual(got.Manifest, want.Manifest) {
+		t.Errorf("meta = %+v, want %+v", got.Manifest, want.Manifest)
+	}
+	if !reflect.DeepEqual(got.Skipped, want.Skipped) {
+		t.Errorf("skipped = %+v, want %+v", got.Skipped, want.Skipped)
+	}
+	if len(got.Nodes) != len(want.Nodes) {
+		t.Fatalf("got %d nodes, want %d", len(got.Nodes), len(want.Nodes))
+	}
+	for i := range want.Nodes {
+		if !reflect.DeepEqual(got.Nodes[i], want.Nodes[i]) {
+			t.Errorf("node %s = %+v, want %+v", want.Nodes[i].Path, got.Nodes[i], want.Nodes[i])
+		}
+	}
+}
+
+func TestTreeLayoutRoundTrip(t *testing.T) {
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	w, err := zw.Create("lib/inner.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("inner"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mtime := time.Unix(1700000000, 0)
+	zeta := fstest.MapFS{
+		"README.md":   {Data: []byte("readme"), ModTime: mtime},
+		"a/x.go":      {Data: []byte("package a"), ModTime: mtime},
+		"a-b/y.go":    {Data: []byte("package ab"), ModTime: mtime},
+		"dist/r.zip":  {Data: zipped.Bytes(), ModTime: mtime},
+		"dist/r.zip2": {Data: []byte("not an archive"), ModTime: mtime},
+	}
+	alpha := fstest.MapFS{"z.txt": {Data: []byte("z"), ModTime: mtime}}
+
+	want, err := manifestor.ScanRoots(context.Background(),
+		[]manifestor.Root{{Name: "zeta", FS: zeta}, {Name: "alpha", FS: alpha}},
+		manifestor.WithArchives(2), manifestor.WithRollups(manifestor.AllRollups()))
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	tree, err := want.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Manifest.Schema.Layout != "tree.v1" {
+		t.Fatalf("layout marker = %q", tree.Manifest.Schema.Layout)
+	}
+	zipNode := tree.Tree["."].Children["zeta"].Children["dist"].Children["r.zip"]
+	if zipNode == nil || zipNode.Children["lib"] == nil || zipNode.Children["lib"].Children["inner.txt"] == nil {
+		t.Fatalf("archive members not nested under their archive: %+v", zipNode)
+	}
+
+	assertLoadsAs(t, tree, want)
+}
+
+func TestCompactLayoutRoundTrip(t *testing.T) {
+	mtime := time.Unix(1700000000, 0)
+	fsys := fstest.MapFS{
+		"README.md":         {Data: []byte("readme"), ModTime: mtime},
+		"src/util/a.go":     {Data: []byte("package util"), ModTime: mtime.Add(time.Minute)},
+		"src/util/b.go":     {Data: []byte("package util // b"), ModTime: mtime},
+		"src/main.go":       {Data: []byte("package main"), ModTime: mtime.Add(time.Hour)},
+		"docs/only-one.txt": {Data: []byte("one")},
+	}
+	want, err := manifestor.ScanFS(context.Background(), fsys, manifestor.WithRollups(manifestor.AllRollups()))
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	c := want.Compact()
+	if c.Manifest.Schema.Layout != "compact.v1" || c.MtimeEpoch != mtime.Unix() {
+		t.Fatalf("layout %q, epoch %d", c.Manifest.Schema.Layout, c.MtimeEpoch)
+	}
+	if !reflect.DeepEqual(c.Prefixes, []string{"src/", "src/util/"}) {
+		t.Errorf("prefixes = %q", c.Prefixes)
+	}
+
+	assertLoadsAs(t, c, want)
+}
+
+// assertLoadsAs writes doc as JSON and YAML and checks that both load back
+// as want.
+func assertLoadsAs(t *testing.T, doc any, want *manifestor.Manifest) {
+	t.Helper()
+
+	dir := t.TempDir()
+	jsonData, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	yamlData, err := yaml.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string][]byte{"m.json": jsonData, "m.yaml": yamlData} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := manifestor.Load(path)
+		if err != nil {
+			t.Fatalf("%s: load: %v", name, err)
+		}
+		if !reflect.DeepEqual(got.Manifest, want.Manifest) || !reflect.DeepEqual(got.Roots, want.Roots) {
+			t.Errorf("%s: meta = %+v, want %+v", name, got.Manifest, want.Manifest)
+		}
+		if len(got.Nodes) != len(want.Nodes) {
+			t.Fatalf("%s: got %d nodes, want %d", name, len(got.Nodes), len(want.Nodes))
+		}
+		for i := range want.Nodes {
+			if !reflect.DeepEqual(got.Nodes[i], want.Nodes[i]) {
+				t.Errorf("%s: node %d = %+v, want %+v", name, i, got.Nodes[i], want.Nodes[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package manifestor
+
+import (
+	"io"
+
+	"github.com/dtnitsch/manifestor/internal/manifest"
+	"github.com/dtnitsch/manifestor/internal/scanner"
+)
+
+// ScanOption configures Scan.
+type ScanOption func(*scanConfig)
+
+type scanConfig struct {
+	scanner scanner.Options
+	filters scanner.FilterSet
+
+	buildRollups bool
+	rollup       []RollupOption
+
+	ndjson io.Writer
+}
+
+// WithBlock skips paths matching any of the rules (unless allowed).
+func WithBlock(rules ...FilterRule) ScanOption {
+	return func(c *scanConfig) { c.filters.Block = append(c.filters.Block, rules...) }
+}
+
+// WithAllow re-includes paths that a block rule would skip.
+func WithAllow(rules ...FilterRule) ScanOption {
+	return func(c *scanConfig) { c.filters.Allow = append(c.filters.Allow, rules...) }
+}
+
+// WithMaxWorkers sets the scanner worker count.
+func WithMaxWorkers(n int) ScanOption {
+	return func(c *scanConfig) { c.scanner.MaxWorkers = n }
+}
+
+// WithInodes toggles inode collection (default on).
+func WithInodes(enabled bool) ScanOption {
+	return func(c *scanConfig) { c.scanner.CollectInodes = enabled }
+}
+
+// WithTimestamps toggles mtime collection (default on).
+func WithTimestamps(enabled bool) ScanOption {
+	return func(c *scanConfig) { c.scanner.CollectTimestamps = enabled }
+}
+
+// WithArchives scans zip, jar, tar and tar.gz files (including a root that
+// is itself an archive) as directories. Members appear under
+// "name.tar.gz!/path"; maxDepth bounds nesting (0 uses the default of 2).
+func WithArchives(maxDepth int) ScanOption {
+	return func(c *scanConfig) {
+		c.scanner.ExpandArchives = true
+		c.scanner.MaxArchiveDepth = maxDepth
+	}
+}
+
+// WithNDJSON streams the manifest to w as NDJSON while scanning instead of
+// collecting nodes: a header record, one record per node, the skipped
+// entries, and a trailer with the rollups. The returned manifest then has
+// metadata and skipped entries but no nodes.
+func WithNDJSON(w io.Writer) ScanOption {
+	return func(c *scanConfig) { c.ndjson = w }
+}
+
+// WithRollups builds directory rollups after the scan.
+func WithRollups(opts ...RollupOption) ScanOption {
+	return func(c *scanConfig) {
+		c.buildRollups = true
+		c.rollup = opts
+	}
+}
+
+// RollupOption configures BuildRollups.
+type RollupOption func(*manifest.RollupOptions)
+
+func rollupOptions(opts []RollupOption) manifest.RollupOptions {
+	var ro manifest.RollupOptions
+	for _, opt := range opts {
+		opt(&ro)
+	}
+	return ro
+}
+
+// WithDirCounts enables descendant directory counts.
+func WithDirCounts() RollupOption {
+	return func(o *manifest.RollupOptions) { o.EnableDirCounts = true }
+}
+
+// WithSizeStats enables size totals, min, max, mean and median.
+func WithSizeStats() RollupOption {
+	return func(o *manifest.RollupOptions) { o.EnableSizeBytes = true }
+}
+
+// WithFileTypes enables per-extension file counts.
+func WithFileTypes() RollupOption {
+	return func(o *manifest.RollupOptions) { o.EnableFileTypes = true }
+}
+
+// WithPercentiles enables p50/p90/p99 size percentiles.
+func WithPercentiles() RollupOption {
+	return func(o *manifest.RollupOptions) { o.EnablePercentiles = true }
+}
+
+// WithDepthStats enables depth statistics.
+func WithDepthStats() RollupOption {
+	return func(o *manifest.RollupOptions) { o.EnableDepthStats = true }
+}
+
+// AllRollups enables every rollup statistic.
+func AllRollups() RollupOption {
+	return func(o *manifest.RollupOptions) {
+		o.EnableDirCounts = true
+		o.EnableSizeBytes = true
+		o.EnableFileTypes = true
+		o.EnablePercentiles = true
+		o.EnableDepthStats = true
+	}
+}
+
+// ValidateOption configures Validate.
+type ValidateOption func(*validateConfig)
+
+type validateConfig struct {
+	opts     manifest.ValidateOptions
+	policies []PolicySpec
+}
+
+// WithLenient downgrades error-severity invariants to warnings.
+func WithLenient() ValidateOption {
+	return func(c *validateConfig) { c.opts.Strict = false }
+}
+
+// WithSeverityOverrides sets the severity of individual invariants.
+func WithSeverityOverrides(overrides map[string]Severity) ValidateOption {
+	return func(c *validateConfig) { c.opts.SeverityOverrides = overrides }
+}
+
+// WithMaxViolations stops collecting after n violations (0 = unlimited).
+func WithMaxViolations(n int) ValidateOption {
+	return func(c *validateConfig) { c.opts.MaxViolations = n }
+}
+
+// WithBaseline suppresses known violations.
+func WithBaseline(b *Baseline) ValidateOption {
+	return func(c *validateConfig) { c.opts.Baseline = b }
+}
+
+// WithPolicies evaluates repository policies alongside the invariants.
+func WithPolicies(specs ...PolicySpec) ValidateOption {
+	return func(c *validateConfig) { c.policies = append(c.policies, specs...) }
+}
+
+// WithCheckers runs additional custom checks.
+func WithCheckers(checkers ...Checker) ValidateOption {
+	return func(c *validateConfig) { c.opts.Checkers = append(c.opts.Checkers, checkers...) }
+}
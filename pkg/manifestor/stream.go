@@ -0,0 +1,39 @@
+package manifestor
+
+import (
+	"github.com/dtnitsch/manifestor/internal/manifest"
+	"github.com/dtnitsch/manifestor/internal/output"
+)
+
+// ndjsonSink connects the scanner to an NDJSON writer, rolling up
+// directories as the walk leaves them.
+type ndjsonSink struct {
+	w       *output.NDJSONWriter
+	rollups *manifest.RollupBuilder
+}
+
+func (s *ndjsonSink) Begin(m *Manifest) error {
+	m.Manifest = manifest.DefaultManifestMeta()
+	if s.rollups != nil {
+		m.Manifest.Capabilities.Rollup = s.rollups.Capabilities()
+	}
+	return s.w.WriteHeader(m)
+}
+
+func (s *ndjsonSink) WriteNode(n *Node) error {
+	if err := s.w.WriteNode(n); err != nil {
+		return err
+	}
+	if s.rollups != nil {
+		s.rollups.Add(n)
+	}
+	return nil
+}
+
+func (s *ndjsonSink) finish(skipped []SkippedEntry) error {
+	var rollups []manifest.DirRollup
+	if s.rollups != nil {
+		rollups = s.rollups.Finish()
+	}
+	return s.w.WriteTrailer(skipped, rollups)
+}
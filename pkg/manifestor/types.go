@@ -0,0 +1,96 @@
+package manifestor
+
+import (
+	"github.com/dtnitsch/manifestor/internal/budget"
+	"github.com/dtnitsch/manifestor/internal/diff"
+	"github.com/dtnitsch/manifestor/internal/filter"
+	"github.com/dtnitsch/manifestor/internal/llm"
+	"github.com/dtnitsch/manifestor/internal/manifest"
+	"github.com/dtnitsch/manifestor/internal/policy"
+	"github.com/dtnitsch/manifestor/internal/scanner"
+	"github.com/dtnitsch/manifestor/internal/subtree"
+	"github.com/dtnitsch/manifestor/internal/verify"
+)
+
+// Manifest model. These aliases are the supported names for the types
+// produced and consumed by this package.
+type (
+	Manifest        = manifest.Manifest
+	ManifestMeta    = manifest.ManifestMeta
+	Node            = manifest.Node
+	Rollup          = manifest.Rollup
+	SkippedEntry    = manifest.SkippedEntry
+	TreeManifest    = manifest.TreeManifest
+	TreeNode        = manifest.TreeNode
+	CompactManifest = manifest.CompactManifest
+)
+
+// Validation results.
+type (
+	ValidationReport   = manifest.ValidationReport
+	InvariantViolation = manifest.InvariantViolation
+	ViolationSummary   = manifest.ViolationSummary
+	Severity           = manifest.Severity
+	Baseline           = manifest.Baseline
+	Checker            = manifest.Checker
+	PolicySpec         = policy.Spec
+)
+
+const (
+	SeverityError   = manifest.SeverityError
+	SeverityWarning = manifest.SeverityWarning
+	SeverityInfo    = manifest.SeverityInfo
+	SeverityOff     = manifest.SeverityOff
+)
+
+// Filter rules.
+type (
+	FilterRule = filter.Rule
+	RuleType   = filter.RuleType
+)
+
+const (
+	Basename = filter.Basename
+	Path     = filter.Path
+)
+
+// Filesystem facts for ScanFS.
+type (
+	Facts   = scanner.Facts
+	FactsFS = scanner.FactsFS
+)
+
+// Roots for ScanRoots.
+type (
+	Root      = scanner.Root
+	FilterSet = scanner.FilterSet
+	RootMeta  = manifest.RootMeta
+)
+
+// Token budgets.
+type (
+	BudgetOptions = budget.Options
+	Estimator     = budget.Estimator
+	CharsPerToken = budget.CharsPerToken
+	BudgetMeta    = manifest.BudgetMeta
+	CollapsedDir  = manifest.CollapsedDir
+)
+
+// DefaultEstimator estimates about 3.5 characters per token.
+const DefaultEstimator = budget.DefaultEstimator
+
+// The manifestor.llm.yaml protocol file.
+type (
+	Protocol        = llm.Protocol
+	ProtocolOptions = llm.Options
+)
+
+// Comparison results.
+type (
+	ChangeSet   = diff.ChangeSet
+	DriftReport = verify.Report
+	SplitPart   = subtree.Part
+)
+
+// CurrentVersion is the manifest format version this library writes.
+const CurrentVersion = manifest.CurrentVersion